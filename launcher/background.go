@@ -0,0 +1,401 @@
+/**
+ * LTTH Launcher - Background Updates
+ *
+ * Downloads started by the user can be limited to downloads.rateLimit KiB/s
+ * so they don't eat the upload of a running stream. With
+ * downloads.background set, the launcher fetches and stages the next version
+ * of the selected channel on its own: throttled to backgroundRateLimit and
+ * only inside the download window (windowStart-windowEnd, local time, e.g.
+ * 03:00-07:00; empty means any time). The staged version is installed next
+ * to the active one, so applying it only switches the active version.
+ *
+ * While the launcher is open a scheduler looks for updates every few
+ * minutes. Since the launcher closes when the app starts, on Windows a daily
+ * task runs "--prefetch" at the start of the window as well.
+ *
+ * Background downloads run at low priority: they stop as soon as the user
+ * starts an install or the window ends and resume from the partial file on
+ * the next attempt. A lock file in the install root keeps the open launcher
+ * and the scheduled task from staging at the same time.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jchv/go-webview2"
+)
+
+const (
+	// prefetchInterval is how often the open launcher looks for an update to stage
+	prefetchInterval = 5 * time.Minute
+	// prefetchRetryDelay is the pause after an attempt to stage an update
+	prefetchRetryDelay = 1 * time.Hour
+	// prefetchTaskName is the Windows scheduled task running "--prefetch"
+	prefetchTaskName = "LTTH Launcher Update"
+	// defaultTaskTime starts the scheduled task when no window is set
+	defaultTaskTime = "03:00"
+)
+
+// DownloadSettings controls download speed and background updates
+type DownloadSettings struct {
+	RateLimit           int    `json:"rateLimit"`
+	Background          bool   `json:"background"`
+	BackgroundRateLimit int    `json:"backgroundRateLimit"`
+	WindowStart         string `json:"windowStart,omitempty"`
+	WindowEnd           string `json:"windowEnd,omitempty"`
+}
+
+// defaultDownloadSettings download at full speed and only when asked to
+func defaultDownloadSettings() DownloadSettings {
+	return DownloadSettings{
+		BackgroundRateLimit: 2048,
+	}
+}
+
+var (
+	// backgroundDownload is set while a background update holds the install slot
+	backgroundDownload atomic.Bool
+	// userWaiting asks a running background download to stop
+	userWaiting atomic.Bool
+
+	foregroundLimiter = &rateLimiter{
+		rate: func() int64 { return int64(downloadSettings().RateLimit) * 1024 },
+	}
+	backgroundLimiter = &rateLimiter{
+		rate: func() int64 { return int64(downloadSettings().BackgroundRateLimit) * 1024 },
+		stop: func() bool { return userWaiting.Load() || !inDownloadWindow(time.Now()) },
+	}
+)
+
+// downloadSettings returns the download settings of the current configuration
+func downloadSettings() DownloadSettings {
+	stateMu.RLock()
+	defer stateMu.RUnlock()
+	return config.Downloads
+}
+
+// currentLimiter returns the limiter for downloads started now
+func currentLimiter() *rateLimiter {
+	if backgroundDownload.Load() {
+		return backgroundLimiter
+	}
+	return foregroundLimiter
+}
+
+// slotMu makes taking and releasing the install slot together with
+// backgroundDownload a single step
+var slotMu sync.Mutex
+
+// errInstallBusy means the install slot is taken by another install, in this
+// or another launcher process
+var errInstallBusy = errors.New("another install is running")
+
+// installLockName is the lock file in the install root. The install slot
+// only covers this process; the lock keeps the open launcher and the
+// scheduled "--prefetch" from staging at the same time.
+const installLockName = ".install.lock"
+
+// installLock is the locked file while this process holds the install slot
+var installLock *os.File
+
+// startInstallTask runs task in the install slot on its own goroutine and
+// reports its result via onInstallResult. A background download holding the
+// slot is asked to stop; waiting for it happens off the UI thread.
+func startInstallTask(w webview2.WebView, task func() string) string {
+	slotMu.Lock()
+	claimed := installing.CompareAndSwap(false, true)
+	if !claimed && !backgroundDownload.Load() {
+		slotMu.Unlock()
+		return `{"success": false, "error": "Installation already running"}`
+	}
+	if !claimed {
+		log.Println("Stopping background download for an install started by the user")
+		userWaiting.Store(true)
+	}
+	slotMu.Unlock()
+
+	go func() {
+		if !claimed && !waitForInstallSlot() {
+			dispatchResult(w, "onInstallResult", `{"success": false, "error": "Installation already running"}`)
+			return
+		}
+		defer installing.Store(false)
+		if !lockInstallRoot() {
+			dispatchResult(w, "onInstallResult", errorResponse("installBusy", fmt.Errorf("a background update of another launcher process is running, try again later")))
+			return
+		}
+		defer unlockInstallRoot()
+		dispatchResult(w, "onInstallResult", task())
+	}()
+	return `{"success": true, "started": true}`
+}
+
+// waitForInstallSlot takes the install slot once a stopping background
+// download has released it. It fails if an install started by the user got
+// the slot first.
+func waitForInstallSlot() bool {
+	defer userWaiting.Store(false)
+	for {
+		slotMu.Lock()
+		claimed := installing.CompareAndSwap(false, true)
+		background := backgroundDownload.Load()
+		slotMu.Unlock()
+		if claimed {
+			return true
+		}
+		if !background {
+			return false
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// claimBackgroundSlot takes the install slot for a background download
+func claimBackgroundSlot() bool {
+	slotMu.Lock()
+	defer slotMu.Unlock()
+	if !installing.CompareAndSwap(false, true) {
+		return false
+	}
+	if !lockInstallRoot() {
+		installing.Store(false)
+		return false
+	}
+	backgroundDownload.Store(true)
+	return true
+}
+
+// releaseBackgroundSlot frees the install slot taken by claimBackgroundSlot
+func releaseBackgroundSlot() {
+	slotMu.Lock()
+	defer slotMu.Unlock()
+	unlockInstallRoot()
+	backgroundDownload.Store(false)
+	installing.Store(false)
+}
+
+// lockInstallRoot takes the install lock shared with other launcher
+// processes; false if one of them holds it. Only the holder of the install
+// slot calls it. File systems without locking are used unlocked.
+func lockInstallRoot() bool {
+	installPath := currentConfig().InstallPath
+	if installPath == "" {
+		return true
+	}
+	if err := os.MkdirAll(installPath, 0755); err != nil {
+		log.Printf("Install lock not available: %v", err)
+		return true
+	}
+	f, err := os.OpenFile(filepath.Join(installPath, installLockName), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		log.Printf("Install lock not available: %v", err)
+		return true
+	}
+	locked, err := tryLockFile(f)
+	if err != nil {
+		log.Printf("Install lock not available: %v", err)
+		f.Close()
+		return true
+	}
+	if !locked {
+		log.Println("Install root is locked by another launcher process")
+		f.Close()
+		return false
+	}
+	installLock = f
+	return true
+}
+
+// unlockInstallRoot releases the lock taken by lockInstallRoot
+func unlockInstallRoot() {
+	if installLock != nil {
+		// Closing the file releases the lock
+		installLock.Close()
+		installLock = nil
+	}
+}
+
+// parseClock parses a time of day like "03:00" into minutes since midnight
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// inDownloadWindow reports whether background downloads are allowed at now.
+// Windows may span midnight (22:00-06:00); an unset or invalid window allows
+// any time.
+func inDownloadWindow(now time.Time) bool {
+	settings := downloadSettings()
+	if settings.WindowStart == "" && settings.WindowEnd == "" {
+		return true
+	}
+	start, err := parseClock(settings.WindowStart)
+	if err != nil {
+		return true
+	}
+	end, err := parseClock(settings.WindowEnd)
+	if err != nil {
+		return true
+	}
+
+	minute := now.Hour()*60 + now.Minute()
+	if start <= end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// pendingUpdate returns the newer release of the selected channel if it can
+// be staged, or "" if there is none
+func pendingUpdate(info *VersionInfo) string {
+	cfg := currentConfig()
+	release, _, ok := selectRelease(info, cfg.Channel)
+	if !ok || cfg.LastVersion == "" || compareVersions(release.Version, cfg.LastVersion) <= 0 {
+		return ""
+	}
+	if isVersionInstalled(release.Version) || !launcherCompatible(info.Artifacts[release.Version].MinLauncherVersion) {
+		return ""
+	}
+	return release.Version
+}
+
+// prefetchUpdate stages the next version of the selected channel without
+// activating it and returns it, or "" if there is nothing to do
+func prefetchUpdate() (string, error) {
+	if !claimBackgroundSlot() {
+		return "", errInstallBusy
+	}
+	defer releaseBackgroundSlot()
+
+	info, err := fetchVersionInfo()
+	if err != nil {
+		return "", err
+	}
+	if info.Offline {
+		return "", fmt.Errorf("update source not reachable")
+	}
+	setVersionInfo(info)
+
+	version := pendingUpdate(info)
+	if version == "" {
+		return "", nil
+	}
+	log.Printf("Staging version %s in the background", version)
+
+	var result struct {
+		Success bool   `json:"success"`
+		Error   string `json:"error"`
+	}
+	json.Unmarshal([]byte(performInstall(version, false, nil)), &result)
+	if !result.Success {
+		return "", fmt.Errorf("staging %s: %s", version, result.Error)
+	}
+	log.Printf("Version %s staged, waiting to be applied", version)
+	return version, nil
+}
+
+// startBackgroundUpdates stages updates while the launcher is open and tells
+// the UI via onUpdateStaged once one is ready
+func startBackgroundUpdates(w webview2.WebView) {
+	go func() {
+		var lastAttempt time.Time
+		ticker := time.NewTicker(prefetchInterval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			if !downloadSettings().Background || !inDownloadWindow(now) || now.Sub(lastAttempt) < prefetchRetryDelay {
+				continue
+			}
+
+			version, err := prefetchUpdate()
+			if errors.Is(err, errInstallBusy) {
+				// Try again on the next tick instead of waiting a whole retry delay
+				continue
+			}
+			lastAttempt = now
+			if err != nil {
+				log.Printf("Background update failed: %v", err)
+				continue
+			}
+			if version != "" {
+				data, _ := json.Marshal(map[string]string{"version": version})
+				dispatchResult(w, "onUpdateStaged", string(data))
+			}
+		}
+	}()
+}
+
+// runHeadlessPrefetch implements "--prefetch" for the scheduled task
+func runHeadlessPrefetch() error {
+	if !downloadSettings().Background {
+		fmt.Println("Background updates are disabled")
+		return nil
+	}
+	if currentConfig().InstallPath == "" {
+		return fmt.Errorf("no install path configured")
+	}
+	if !inDownloadWindow(time.Now()) {
+		fmt.Println("Outside the download window")
+		return nil
+	}
+
+	enterBackgroundMode()
+	version, err := prefetchUpdate()
+	if errors.Is(err, errInstallBusy) {
+		fmt.Println("Another install is running, background update skipped")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if version == "" {
+		fmt.Println("No update to stage")
+	} else {
+		fmt.Printf("Staged version %s\n", version)
+	}
+	return nil
+}
+
+// updatePrefetchTask creates or removes the scheduled task running
+// "--prefetch" daily at the start of the download window
+func updatePrefetchTask() error {
+	if runtime.GOOS != "windows" {
+		return nil
+	}
+	settings := downloadSettings()
+	if !settings.Background {
+		// Fails harmlessly if there is no task
+		exec.Command("schtasks", "/Delete", "/F", "/TN", prefetchTaskName).Run()
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	start := settings.WindowStart
+	if start == "" {
+		start = defaultTaskTime
+	}
+	output, err := exec.Command("schtasks", "/Create", "/F", "/SC", "DAILY", "/ST", start,
+		"/TN", prefetchTaskName, "/TR", fmt.Sprintf(`"%s" --prefetch`, exe)).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("scheduled task: %v: %s", err, output)
+	}
+	log.Printf("Scheduled background updates daily at %s", start)
+	return nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInstallLockExcludesOtherProcesses(t *testing.T) {
+	stateMu.Lock()
+	oldConfig := config
+	config = LauncherConfig{InstallPath: t.TempDir()}
+	stateMu.Unlock()
+	t.Cleanup(func() {
+		stateMu.Lock()
+		config = oldConfig
+		stateMu.Unlock()
+	})
+
+	if !claimBackgroundSlot() {
+		t.Fatal("claimBackgroundSlot() failed on a free install root")
+	}
+
+	// Another process opens and locks the same file independently
+	other, err := os.OpenFile(filepath.Join(currentConfig().InstallPath, installLockName), os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if locked, err := tryLockFile(other); locked || err != nil {
+		t.Fatalf("tryLockFile() = %v, %v while the slot is held", locked, err)
+	}
+
+	releaseBackgroundSlot()
+	if locked, err := tryLockFile(other); !locked || err != nil {
+		t.Fatalf("tryLockFile() = %v, %v after the slot was released", locked, err)
+	}
+
+	// Now the install root is locked elsewhere, so the slot can't be taken
+	if claimBackgroundSlot() {
+		releaseBackgroundSlot()
+		t.Fatal("claimBackgroundSlot() succeeded while another process holds the lock")
+	}
+	if installing.Load() {
+		t.Error("failed claim left the install slot taken")
+	}
+}
@@ -33,6 +33,26 @@ if ! command -v x86_64-w64-mingw32-gcc &> /dev/null; then
 fi
 echo -e "  ${GREEN}✓ MinGW-w64 found${NC}"
 
+# Release builds must embed at least one signing key
+LDFLAGS_EXTRA=""
+if ! grep -qv '^\s*\(#\|$\)' keys/trusted.txt; then
+    if [ "$ALLOW_UNSIGNED" != "1" ]; then
+        echo "Error: keys/trusted.txt contains no release signing key"
+        echo "Add one with: go run ./cmd/ltth-sign keygen (or set ALLOW_UNSIGNED=1)"
+        exit 1
+    fi
+    echo -e "  ${YELLOW}⚠ No signing key embedded - signature verification disabled${NC}"
+    LDFLAGS_EXTRA="-X main.allowUnsigned=1"
+else
+    # A launcher with keys only installs signed releases with checksums
+    FEED_FILE="${FEED_FILE:-../version.json}"
+    if [ "$SKIP_FEED_CHECK" != "1" ] && ! go run ./cmd/ltth-sign verify -feed "$FEED_FILE"; then
+        echo "Error: $FEED_FILE is unsigned or lacks checksums, this launcher could not install updates"
+        echo "Finish the signed release rollout first (docs/SECURITY.md) or set SKIP_FEED_CHECK=1"
+        exit 1
+    fi
+fi
+
 # Build
 echo -e "${YELLOW}[3/4] Building cloud launcher...${NC}"
 export GOOS=windows
@@ -40,7 +60,7 @@ export GOARCH=amd64
 export CGO_ENABLED=1
 export CC=x86_64-w64-mingw32-gcc
 
-go build -ldflags="-H windowsgui -s -w" -o launcher.exe .
+go build -ldflags="-H windowsgui -s -w $LDFLAGS_EXTRA" -o launcher.exe .
 
 if [ ! -f "launcher.exe" ]; then
     echo "Error: Build failed"
@@ -53,6 +53,28 @@ if ($LASTEXITCODE -ne 0) {
 }
 Write-Host "  ✓ Dependencies geladen" -ForegroundColor Green
 
+# Release-Builds brauchen mindestens einen Signierschlüssel
+$signingKeys = Get-Content "keys/trusted.txt" | Where-Object { $_.Trim() -ne "" -and -not $_.Trim().StartsWith("#") }
+$unsigned = $false
+if (-not $signingKeys) {
+    if ($env:ALLOW_UNSIGNED -ne "1") {
+        Write-Host "  ✗ keys/trusted.txt enthält keinen Signierschlüssel" -ForegroundColor Red
+        Write-Host "  Schlüssel erzeugen mit: go run ./cmd/ltth-sign keygen (oder `$env:ALLOW_UNSIGNED=1 für Entwicklungs-Builds)" -ForegroundColor Yellow
+        exit 1
+    }
+    Write-Host "  ⚠ Kein Signierschlüssel eingebettet - Entwicklungs-Build ohne Signaturprüfung" -ForegroundColor Yellow
+    $unsigned = $true
+} elseif ($env:SKIP_FEED_CHECK -ne "1") {
+    # Ein Launcher mit Schlüssel kann nur signierte Releases mit Checksumme installieren
+    $feedFile = if ($env:FEED_FILE) { $env:FEED_FILE } else { "../version.json" }
+    go run ./cmd/ltth-sign verify -feed $feedFile
+    if ($LASTEXITCODE -ne 0) {
+        Write-Host "  ✗ $feedFile ist nicht signiert oder ohne Checksummen - dieser Launcher könnte keine Updates installieren" -ForegroundColor Red
+        Write-Host "  Erst die Umstellung auf signierte Releases abschließen (docs/SECURITY.md) oder `$env:SKIP_FEED_CHECK=1 setzen" -ForegroundColor Yellow
+        exit 1
+    }
+}
+
 # Build-Flags setzen
 Write-Host "[4/5] Baue Launcher..." -ForegroundColor Yellow
 
@@ -60,6 +82,9 @@ $ldflags = "-H windowsgui"
 if (!$Debug) {
     $ldflags = "-H windowsgui -s -w"  # Strip debug info für kleinere Datei
 }
+if ($unsigned) {
+    $ldflags += " -X main.allowUnsigned=1"
+}
 
 $env:GOOS = "windows"
 $env:GOARCH = "amd64"
@@ -42,6 +42,26 @@ echo -e "${YELLOW}[3/5] Lade Dependencies...${NC}"
 go mod tidy
 echo -e "  ${GREEN}✓ Dependencies geladen${NC}"
 
+# Release-Builds brauchen mindestens einen Signierschlüssel
+LDFLAGS_EXTRA=""
+if ! grep -qv '^\s*\(#\|$\)' keys/trusted.txt; then
+    if [ "$ALLOW_UNSIGNED" != "1" ]; then
+        echo -e "  ${RED}✗ keys/trusted.txt enthält keinen Signierschlüssel${NC}"
+        echo -e "  ${YELLOW}Schlüssel erzeugen mit: go run ./cmd/ltth-sign keygen (oder ALLOW_UNSIGNED=1 für Entwicklungs-Builds)${NC}"
+        exit 1
+    fi
+    echo -e "  ${YELLOW}⚠ Kein Signierschlüssel eingebettet - Entwicklungs-Build ohne Signaturprüfung${NC}"
+    LDFLAGS_EXTRA="-X main.allowUnsigned=1"
+else
+    # Ein Launcher mit Schlüssel kann nur signierte Releases mit Checksumme installieren
+    FEED_FILE="${FEED_FILE:-../version.json}"
+    if [ "$SKIP_FEED_CHECK" != "1" ] && ! go run ./cmd/ltth-sign verify -feed "$FEED_FILE"; then
+        echo -e "  ${RED}✗ $FEED_FILE ist nicht signiert oder ohne Checksummen - dieser Launcher könnte keine Updates installieren${NC}"
+        echo -e "  ${YELLOW}Erst die Umstellung auf signierte Releases abschließen (docs/SECURITY.md) oder SKIP_FEED_CHECK=1 setzen${NC}"
+        exit 1
+    fi
+fi
+
 # Build
 echo -e "${YELLOW}[4/5] Baue Launcher...${NC}"
 
@@ -52,12 +72,12 @@ export GOARCH=amd64
 if command -v x86_64-w64-mingw32-gcc &> /dev/null; then
     export CGO_ENABLED=1
     export CC=x86_64-w64-mingw32-gcc
-    go build -ldflags="-H windowsgui -s -w" -o launcher.exe .
+    go build -ldflags="-H windowsgui -s -w $LDFLAGS_EXTRA" -o launcher.exe .
 else
     # Fallback ohne CGO (WebView2 benötigt CGO)
     export CGO_ENABLED=0
     echo -e "  ${YELLOW}⚠ Baue ohne CGO - WebView2 wird nicht funktionieren${NC}"
-    go build -ldflags="-s -w" -o launcher.exe . 2>/dev/null || {
+    go build -ldflags="-s -w $LDFLAGS_EXTRA" -o launcher.exe . 2>/dev/null || {
         echo -e "  ${RED}✗ Build ohne CGO fehlgeschlagen${NC}"
         echo -e "  ${YELLOW}WebView2 benötigt CGO. Installiere MinGW:${NC}"
         echo -e "  ${YELLOW}sudo apt install gcc-mingw-w64-x86-64${NC}"
@@ -0,0 +1,162 @@
+/**
+ * LTTH Launcher - Metadata Cache
+ *
+ * version.json and GitHub release lists are cached on disk together with
+ * their ETag / Last-Modified and, for version.json, the signature. A copy
+ * younger than manifestMaxAge (minutes, launcher config) is used without a
+ * request; older copies are revalidated with a conditional GET. Without a
+ * connection the last cached copy is shown together with its age.
+ *
+ * Cached manifests are verified again on every use, so a modified cache file
+ * is never trusted.
+ */
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// defaultManifestMaxAge is how many minutes a cached manifest is used without asking the server
+	defaultManifestMaxAge = 5
+	// maxManifestSize limits the size of a downloaded manifest
+	maxManifestSize = 8 << 20
+)
+
+// cachedResponse is a cached manifest and what is needed to revalidate it
+type cachedResponse struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	FetchedAt    time.Time `json:"fetchedAt"`
+	Data         []byte    `json:"data"`
+	Signature    []byte    `json:"signature,omitempty"`
+}
+
+// manifestCacheDir returns the directory cached manifests are kept in
+func manifestCacheDir() string {
+	return filepath.Join(filepath.Dir(configPath), "cache")
+}
+
+// manifestCachePath returns the cache file for url
+func manifestCachePath(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(manifestCacheDir(), hex.EncodeToString(sum[:8])+".json")
+}
+
+// loadCachedResponse returns the cached copy of url, or nil if there is none
+func loadCachedResponse(url string) *cachedResponse {
+	data, err := os.ReadFile(manifestCachePath(url))
+	if err != nil {
+		return nil
+	}
+	var cached cachedResponse
+	if err := json.Unmarshal(data, &cached); err != nil || cached.URL != url {
+		log.Printf("Ignoring invalid cache entry for %s", url)
+		return nil
+	}
+	return &cached
+}
+
+// storeCachedResponse writes a cache entry, replacing the previous one atomically
+func storeCachedResponse(cached *cachedResponse) {
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return
+	}
+	path := manifestCachePath(cached.URL)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		log.Printf("Error caching %s: %v", cached.URL, err)
+		return
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		log.Printf("Error caching %s: %v", cached.URL, err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		log.Printf("Error caching %s: %v", cached.URL, err)
+	}
+}
+
+// manifestMaxAge returns how long a cached manifest is used without a request
+func manifestMaxAge() time.Duration {
+	maxAge := currentConfig().ManifestMaxAge
+	if maxAge < 0 {
+		return 0
+	}
+	return time.Duration(maxAge) * time.Minute
+}
+
+// fresh reports whether the cached copy can be used without asking the server
+func (c *cachedResponse) fresh(now time.Time) bool {
+	age := now.Sub(c.FetchedAt)
+	return age >= 0 && age < manifestMaxAge()
+}
+
+// fetchConditional downloads url with client, revalidating cached if given.
+// It returns the response to use and whether it differs from cached; on
+// "304 Not Modified" that is cached with an updated fetch time.
+func fetchConditional(client *http.Client, req *http.Request, cached *cachedResponse) (*cachedResponse, bool, error) {
+	url := req.URL.String()
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		log.Printf("%s not modified since %s", url, cached.FetchedAt.Format(time.RFC3339))
+		revalidated := *cached
+		revalidated.FetchedAt = time.Now()
+		return &revalidated, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, &httpStatusError{Status: resp.Status, Header: resp.Header}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize+1))
+	if err != nil {
+		return nil, false, err
+	}
+	if len(data) > maxManifestSize {
+		return nil, false, fmt.Errorf("%s exceeds %d bytes", url, maxManifestSize)
+	}
+	return &cachedResponse{
+		URL:          url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FetchedAt:    time.Now(),
+		Data:         data,
+	}, true, nil
+}
+
+// httpStatusError is a response other than 200 or 304
+type httpStatusError struct {
+	Status string
+	Header http.Header
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("bad status: %s", e.Status)
+}
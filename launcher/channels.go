@@ -0,0 +1,74 @@
+/**
+ * LTTH Launcher - Release Channels
+ *
+ * version.json can publish one release per channel. A launcher on a channel
+ * is offered the newest release of that channel or of any more stable one,
+ * so "beta" users also receive stable releases that overtake the beta.
+ */
+
+package main
+
+// Release channels, ordered from most to least stable
+const (
+	ChannelStable  = "stable"
+	ChannelBeta    = "beta"
+	ChannelNightly = "nightly"
+)
+
+var channelRank = map[string]int{
+	ChannelStable:  0,
+	ChannelBeta:    1,
+	ChannelNightly: 2,
+}
+
+// ChannelRelease is the current release of one channel in version.json
+type ChannelRelease struct {
+	Version     string `json:"version"`
+	ReleaseDate string `json:"releaseDate"`
+}
+
+// isValidChannel reports whether name is a known release channel
+func isValidChannel(name string) bool {
+	_, ok := channelRank[name]
+	return ok
+}
+
+// channelReleases returns the release per channel. Manifests without a
+// channels section publish a single release whose channel is its status.
+func channelReleases(info *VersionInfo) map[string]ChannelRelease {
+	if len(info.Channels) > 0 {
+		return info.Channels
+	}
+	status := info.Status
+	if !isValidChannel(status) {
+		status = ChannelStable
+	}
+	return map[string]ChannelRelease{
+		status: {Version: info.Version, ReleaseDate: info.ReleaseDate},
+	}
+}
+
+// selectRelease picks the newest release available to the given channel and
+// returns it together with the channel it was published in
+func selectRelease(info *VersionInfo, channel string) (ChannelRelease, string, bool) {
+	rank, ok := channelRank[channel]
+	if !ok {
+		rank = channelRank[ChannelStable]
+	}
+
+	var best ChannelRelease
+	bestChannel := ""
+	for name, release := range channelReleases(info) {
+		r, known := channelRank[name]
+		if !known || r > rank || release.Version == "" {
+			continue
+		}
+		// Prefer the newer version; on a tie prefer the more stable channel
+		cmp := compareVersions(release.Version, best.Version)
+		if bestChannel == "" || cmp > 0 || (cmp == 0 && r < channelRank[bestChannel]) {
+			best = release
+			bestChannel = name
+		}
+	}
+	return best, bestChannel, bestChannel != ""
+}
@@ -0,0 +1,426 @@
+/**
+ * LTTH Release Signing Tool
+ *
+ * Creates release signing keys and detached signatures for version.json and
+ * release archives. The launcher verifies them with the keys in keys/trusted.txt.
+ * Also unpacks a release archive (ZIP, tar.gz or tar.zst) into a file list
+ * for delta updates.
+ *
+ * Usage:
+ *   go run ./cmd/ltth-sign keygen -out release.key
+ *   go run ./cmd/ltth-sign sign -key release.key version.json app/ltth_latest.zip
+ *   go run ./cmd/ltth-sign verify -keys keys/trusted.txt version.json
+ *   go run ./cmd/ltth-sign verify -feed ../version.json
+ *   go run ./cmd/ltth-sign files -version 1.2.1 -out app/files/1.2.1 app/ltth_latest.zip
+ */
+
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+
+	"ltth-launcher/signing"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "keygen":
+		err = keygen(os.Args[2:])
+	case "sign":
+		err = sign(os.Args[2:])
+	case "verify":
+		err = verify(os.Args[2:])
+	case "files":
+		err = files(os.Args[2:])
+	default:
+		usage()
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: ltth-sign <keygen|sign|verify|files> [flags] [files...]")
+	fmt.Fprintln(os.Stderr, "  keygen -out <private key file>")
+	fmt.Fprintln(os.Stderr, "  sign   -key <private key file> <file>...")
+	fmt.Fprintln(os.Stderr, "  verify -keys <trusted keys file> [-revoked <revoked ids file>] [-feed] <file>...")
+	fmt.Fprintln(os.Stderr, "  files  -version <version> -out <folder> <archive.zip|.tar.gz|.tar.zst>")
+	os.Exit(2)
+}
+
+// keygen writes a new private key and prints the public key line for keys/trusted.txt
+func keygen(args []string) error {
+	fs := flag.NewFlagSet("keygen", flag.ExitOnError)
+	out := fs.String("out", "release.key", "private key output file")
+	fs.Parse(args)
+
+	if _, err := os.Stat(*out); err == nil {
+		return fmt.Errorf("%s already exists, refusing to overwrite", *out)
+	}
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	seed := base64.StdEncoding.EncodeToString(priv.Seed())
+	if err := os.WriteFile(*out, []byte(seed+"\n"), 0600); err != nil {
+		return err
+	}
+
+	fmt.Printf("Private key written to %s (keep it secret, never commit it)\n", *out)
+	fmt.Printf("Key ID: %s\n", signing.KeyID(pub))
+	fmt.Println("Add this line to launcher/keys/trusted.txt:")
+	fmt.Printf("%s %s\n", base64.StdEncoding.EncodeToString(pub), signing.KeyID(pub))
+	return nil
+}
+
+// sign adds a signature to <file>.sig for every file, keeping signatures of other keys
+func sign(args []string) error {
+	fs := flag.NewFlagSet("sign", flag.ExitOnError)
+	keyFile := fs.String("key", "release.key", "private key file")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("no files to sign")
+	}
+
+	priv, err := loadPrivateKey(*keyFile)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range fs.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		sig, err := signing.Sign(priv, f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+
+		sigPath := path + signing.SignatureExt
+		var sigFile signing.SignatureFile
+		if data, err := os.ReadFile(sigPath); err == nil {
+			json.Unmarshal(data, &sigFile)
+		}
+
+		// Replace an older signature of the same key, keep all others
+		kept := sigFile.Signatures[:0]
+		for _, s := range sigFile.Signatures {
+			if s.KeyID != sig.KeyID {
+				kept = append(kept, s)
+			}
+		}
+		sigFile.Signatures = append(kept, sig)
+
+		data, err := json.MarshalIndent(sigFile, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(sigPath, data, 0644); err != nil {
+			return err
+		}
+		fmt.Printf("Signed %s with key %s -> %s\n", path, sig.KeyID, sigPath)
+	}
+	return nil
+}
+
+// verify checks files against their .sig files like the launcher does. With
+// -feed each file is a version.json that must also publish a checksum for
+// every offered release, so a launcher built with these keys can install it.
+func verify(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	keysFile := fs.String("keys", "keys/trusted.txt", "trusted public keys file")
+	revokedFile := fs.String("revoked", "keys/revoked.txt", "revoked key IDs file")
+	feed := fs.Bool("feed", false, "files are version.json feeds; check their checksums too")
+	fs.Parse(args)
+
+	data, err := os.ReadFile(*keysFile)
+	if err != nil {
+		return err
+	}
+	keys, err := signing.ParseKeyList(data)
+	if err != nil {
+		return err
+	}
+	var revoked []string
+	if data, err := os.ReadFile(*revokedFile); err == nil {
+		revoked = signing.ParseIDList(data)
+	}
+	ring := signing.NewKeyRing(keys, revoked)
+
+	failed := false
+	for _, path := range fs.Args() {
+		sig, err := os.ReadFile(path + signing.SignatureExt)
+		if err == nil {
+			err = ring.VerifyFile(path, sig)
+		}
+		if err == nil && *feed {
+			err = checkFeed(path)
+		}
+		if err != nil {
+			fmt.Printf("FAIL %s: %v\n", path, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("OK   %s\n", path)
+	}
+	if failed {
+		return fmt.Errorf("verification failed")
+	}
+	return nil
+}
+
+// checkFeed reports an error unless the version.json at path publishes a
+// SHA256 for the current version and every channel release
+func checkFeed(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var feed struct {
+		Version  string `json:"version"`
+		Channels map[string]struct {
+			Version string `json:"version"`
+		} `json:"channels"`
+		Artifacts map[string]struct {
+			SHA256 string `json:"sha256"`
+		} `json:"artifacts"`
+	}
+	if err := json.Unmarshal(data, &feed); err != nil {
+		return fmt.Errorf("invalid version.json: %v", err)
+	}
+
+	offered := []string{feed.Version}
+	for _, channel := range feed.Channels {
+		offered = append(offered, channel.Version)
+	}
+	var missing []string
+	for _, version := range offered {
+		if version != "" && feed.Artifacts[version].SHA256 == "" {
+			missing = append(missing, version)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("no artifacts.<version>.sha256 for %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// fileList is the file list format the launcher reads for delta updates
+type fileList struct {
+	Version string      `json:"version"`
+	Files   []fileEntry `json:"files"`
+}
+
+type fileEntry struct {
+	Path       string    `json:"path"`
+	Size       int64     `json:"size"`
+	SHA256     string    `json:"sha256"`
+	Executable bool      `json:"executable,omitempty"`
+	ModTime    time.Time `json:"modTime"`
+}
+
+// files unpacks a release archive into a folder and writes its files.json
+func files(args []string) error {
+	fs := flag.NewFlagSet("files", flag.ExitOnError)
+	version := fs.String("version", "", "version the archive contains")
+	out := fs.String("out", "", "output folder, published next to files.json")
+	fs.Parse(args)
+
+	if *version == "" || *out == "" || fs.NArg() != 1 {
+		return fmt.Errorf("files needs -version, -out and exactly one archive")
+	}
+
+	list := fileList{Version: *version, Files: []fileEntry{}}
+	unpacked, longest := int64(0), 0
+	err := walkArchive(fs.Arg(0), func(archiveName string, mode os.FileMode, modTime time.Time, r io.Reader) error {
+		name := filepath.Clean(filepath.FromSlash(archiveName))
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("invalid path in archive: %s", archiveName)
+		}
+
+		entry, err := unpackFile(r, filepath.Join(*out, name))
+		if err != nil {
+			return fmt.Errorf("%s: %v", archiveName, err)
+		}
+		entry.Path = filepath.ToSlash(name)
+		entry.Executable = mode&0111 != 0
+		entry.ModTime = modTime.UTC()
+		list.Files = append(list.Files, entry)
+		unpacked += entry.Size
+		if len(entry.Path) > longest {
+			longest = len(entry.Path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Slice(list.Files, func(i, j int) bool { return list.Files[i].Path < list.Files[j].Path })
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	listPath := filepath.Join(*out, "files.json")
+	if err := os.WriteFile(listPath, data, 0644); err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(data)
+	fmt.Printf("Wrote %d files and %s\n", len(list.Files), listPath)
+	fmt.Printf("Add to artifacts.%s in version.json: \"files\": \"<url of files.json>\", \"filesSha256\": \"%s\", \"unpackedSize\": %d, \"maxPathLength\": %d\n",
+		*version, hex.EncodeToString(sum[:]), unpacked, longest)
+	fmt.Printf("Then sign it: ltth-sign sign -key <key> %s\n", listPath)
+	return nil
+}
+
+// walkArchive calls fn for each regular file of a ZIP, tar.gz or tar.zst
+// archive. File lists only describe regular files, so archives with links
+// are rejected instead of producing a version without them.
+func walkArchive(path string, fn func(name string, mode os.FileMode, modTime time.Time, r io.Reader) error) error {
+	name := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return walkZip(path, fn)
+	case strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz"):
+		return walkTar(path, fn, func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		})
+	case strings.HasSuffix(name, ".tar.zst") || strings.HasSuffix(name, ".tzst"):
+		return walkTar(path, fn, func(r io.Reader) (io.ReadCloser, error) {
+			d, err := zstd.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			return d.IOReadCloser(), nil
+		})
+	}
+	return fmt.Errorf("unsupported archive %s, expected .zip, .tar.gz or .tar.zst", path)
+}
+
+func walkZip(path string, fn func(name string, mode os.FileMode, modTime time.Time, r io.Reader) error) error {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		mode := f.Mode()
+		if mode.IsDir() {
+			continue
+		}
+		if !mode.IsRegular() {
+			return fmt.Errorf("%s: links and special files can't be published in a file list", f.Name)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		err = fn(f.Name, mode, f.Modified, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkTar(path string, fn func(name string, mode os.FileMode, modTime time.Time, r io.Reader) error, decompress func(io.Reader) (io.ReadCloser, error)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	stream, err := decompress(f)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	tr := tar.NewReader(stream)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch hdr.Typeflag {
+		case tar.TypeXGlobalHeader, tar.TypeDir:
+			continue
+		case tar.TypeReg:
+		default:
+			return fmt.Errorf("%s: links and special files can't be published in a file list", hdr.Name)
+		}
+		if err := fn(strings.TrimPrefix(hdr.Name, "./"), hdr.FileInfo().Mode(), hdr.ModTime, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// unpackFile writes one archive entry to dest and returns its size and hash
+func unpackFile(r io.Reader, dest string) (fileEntry, error) {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fileEntry{}, err
+	}
+	out, err := os.Create(dest)
+	if err != nil {
+		return fileEntry{}, err
+	}
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(out, hash), r)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fileEntry{}, err
+	}
+	return fileEntry{Size: size, SHA256: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// loadPrivateKey reads a base64 encoded Ed25519 seed
+func loadPrivateKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid private key file: %s", path)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
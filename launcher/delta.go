@@ -0,0 +1,292 @@
+/**
+ * LTTH Launcher - Delta Updates
+ *
+ * artifacts.<version>.files points to the file list of a version in the
+ * install manifest format (path, size, sha256). Files whose hash matches a
+ * file of the active version are copied locally; only changed files are
+ * downloaded, from the folder the file list lives in. Each file is tried on
+ * every mirror, starting with the one that served the list. Copies keep the
+ * permissions of the local file, files marked executable in the list get
+ * 0755. Every file gets the modification time from the list, like extracted
+ * ones. The list is held to the same extractLimits as an archive. Any failure
+ * falls back to the full archive.
+ */
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// errNoDelta means no delta update is possible and the full archive is needed
+var errNoDelta = errors.New("no delta update available")
+
+// maxFileListSize limits the size of a remote file list
+const maxFileListSize = 16 << 20
+
+// stageDelta builds version in a staging folder from the active version plus
+// the changed files and returns the staging folder ready to be committed
+func stageDelta(info *VersionInfo, version string, report ProgressFunc) (string, error) {
+	artifact := info.Artifacts[version]
+	cfg := currentConfig()
+	if artifact.Files == "" || cfg.LastVersion == "" || cfg.LastVersion == version {
+		return "", errNoDelta
+	}
+	baseDir := filepath.Join(cfg.InstallPath, cfg.LastVersion)
+	base, err := loadInstallManifest(baseDir)
+	if err != nil {
+		return "", errNoDelta
+	}
+
+	remote, fileBases, err := fetchVersionFileList(artifact, version)
+	if err != nil {
+		return "", err
+	}
+
+	// Index the active version by content so renamed files are reused as well
+	local := make(map[string]string, len(base.Files))
+	for _, file := range base.Files {
+		local[strings.ToLower(file.SHA256)] = file.Path
+	}
+
+	staging, err := newStagingDir(version)
+	if err != nil {
+		return "", err
+	}
+
+	copied, downloaded, downloadedBytes := 0, 0, int64(0)
+	progress := newProgressTracker(PhaseDownload, UnitFiles, int64(len(remote.Files)), report)
+	for _, file := range remote.Files {
+		if !isSafeManifestPath(file.Path) {
+			os.RemoveAll(staging)
+			return "", fmt.Errorf("invalid path in file list: %q", file.Path)
+		}
+		dest := filepath.Join(staging, filepath.FromSlash(file.Path))
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			os.RemoveAll(staging)
+			return "", err
+		}
+
+		if src, ok := local[strings.ToLower(file.SHA256)]; ok {
+			err := copyVerified(filepath.Join(baseDir, filepath.FromSlash(src)), dest, file)
+			if err == nil {
+				copied++
+				progress.Add(1)
+				continue
+			}
+			// The local file changed since it was installed; download it instead
+			log.Printf("Delta: local copy of %s unusable: %v", file.Path, err)
+		}
+
+		if err := downloadDeltaFile(dest, file, fileBases); err != nil {
+			os.RemoveAll(staging)
+			return "", fmt.Errorf("download of %s failed: %w", file.Path, err)
+		}
+		downloaded++
+		downloadedBytes += file.Size
+		progress.Add(1)
+	}
+	progress.Finish()
+
+	// The verified file list becomes the install manifest of the new version
+	data, _ := json.MarshalIndent(InstallManifest{Version: version, Files: remote.Files}, "", "  ")
+	if err := os.WriteFile(filepath.Join(staging, installManifestName), data, 0644); err != nil {
+		os.RemoveAll(staging)
+		return "", err
+	}
+	if err := finishStaging(staging, version); err != nil {
+		os.RemoveAll(staging)
+		return "", err
+	}
+
+	log.Printf("Delta update to %s: %d files reused from %s, %d files (%d bytes) downloaded",
+		version, copied, cfg.LastVersion, downloaded, downloadedBytes)
+	return staging, nil
+}
+
+// fetchVersionFileList fetches the file list of version from the first mirror
+// that serves it. It also returns the folders to download listed files from,
+// that mirror first.
+func fetchVersionFileList(artifact ArtifactInfo, version string) (*InstallManifest, []mirrorSource, error) {
+	var list *InstallManifest
+	var err error
+	sources := artifactSources(artifact.Files)
+	for i, source := range sources {
+		if list, err = fetchFileList(source.URL, artifact.FilesSHA256); err == nil {
+			recordMirrorSuccess(source.Mirror)
+			sources[0], sources[i] = sources[i], sources[0]
+			break
+		}
+		recordMirrorFailure(source.Mirror, err)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if list.Version != version {
+		return nil, nil, fmt.Errorf("file list is for version %q, expected %q", list.Version, version)
+	}
+	fileBases := make([]mirrorSource, len(sources))
+	for i, source := range sources {
+		fileBases[i] = mirrorSource{Mirror: source.Mirror, URL: source.URL[:strings.LastIndex(source.URL, "/")+1]}
+	}
+	return list, fileBases, nil
+}
+
+// downloadDeltaFile downloads and verifies one changed file, trying the file
+// folder on each mirror in turn, and sets its mode and modification time
+func downloadDeltaFile(dest string, file ManifestFile, bases []mirrorSource) error {
+	var lastErr error
+	for i, base := range bases {
+		sum, err := downloadFromMirror(dest, base.URL+escapeURLPath(file.Path), i == len(bases)-1, nil)
+		if err == nil {
+			err = verifyDownload(dest, file.Size, sum, file.SHA256)
+		}
+		if err == nil {
+			recordMirrorSuccess(base.Mirror)
+			if file.Executable {
+				os.Chmod(dest, 0755)
+			}
+			if !file.ModTime.IsZero() {
+				os.Chtimes(dest, file.ModTime, file.ModTime)
+			}
+			return nil
+		}
+		recordMirrorFailure(base.Mirror, err)
+		os.Remove(dest)
+		lastErr = err
+	}
+	return lastErr
+}
+
+// fetchFileList downloads and verifies the file list of a version
+func fetchFileList(listURL, expectedSHA256 string) (*InstallManifest, error) {
+	resp, err := httpClient().Get(listURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("file list not available: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFileListSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxFileListSize {
+		return nil, fmt.Errorf("file list exceeds %d bytes", maxFileListSize)
+	}
+
+	if expectedSHA256 != "" {
+		sum := sha256.Sum256(data)
+		if !strings.EqualFold(hex.EncodeToString(sum[:]), expectedSHA256) {
+			return nil, fmt.Errorf("file list checksum mismatch")
+		}
+	}
+	if err := verifyManifestSignature(data, listURL); err != nil {
+		return nil, err
+	}
+
+	var list InstallManifest
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid file list: %v", err)
+	}
+	if err := checkFileList(&list, extractLimits()); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// checkFileList rejects file lists beyond the limits extractArchive enforces
+func checkFileList(list *InstallManifest, limits ExtractLimits) error {
+	if len(list.Files) > limits.MaxEntries {
+		return unsafeArchive("%d entries exceed the limit of %d", len(list.Files), limits.MaxEntries)
+	}
+	var total int64
+	for _, file := range list.Files {
+		if file.Size < 0 {
+			return unsafeArchive("%s: invalid size %d", file.Path, file.Size)
+		}
+		if file.Size > limits.MaxFileSize {
+			return unsafeArchive("%s: %d bytes exceed the file size limit of %d", file.Path, file.Size, limits.MaxFileSize)
+		}
+		if total += file.Size; total > limits.MaxTotalSize {
+			return unsafeArchive("unpacked size exceeds the limit of %d", limits.MaxTotalSize)
+		}
+	}
+	return nil
+}
+
+// copyVerified copies src with its permissions to dest and fails unless the
+// copy matches file. The modification time is the one from file if known.
+func copyVerified(src, dest string, file ManifestFile) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	mode := restoredMode(info.Mode())
+	if file.Executable {
+		mode |= 0111 & safePermMask
+	}
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+	written, err := io.Copy(io.MultiWriter(out, hash), in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && written != file.Size {
+		err = fmt.Errorf("size mismatch: expected %d bytes, got %d", file.Size, written)
+	}
+	if err == nil && !strings.EqualFold(hex.EncodeToString(hash.Sum(nil)), file.SHA256) {
+		err = fmt.Errorf("checksum mismatch")
+	}
+	if err == nil {
+		err = os.Chmod(dest, mode)
+	}
+	if err != nil {
+		os.Remove(dest)
+		return err
+	}
+	modTime := info.ModTime()
+	if !file.ModTime.IsZero() {
+		modTime = file.ModTime
+	}
+	os.Chtimes(dest, modTime, modTime)
+	return nil
+}
+
+// isSafeManifestPath reports whether a file list path stays inside the version folder
+func isSafeManifestPath(rel string) bool {
+	return rel != "" && !strings.Contains(rel, "\\") && !isInstallMetadata(rel) &&
+		filepath.IsLocal(filepath.FromSlash(rel))
+}
+
+// escapeURLPath escapes each segment of a slash-separated path for use in a URL
+func escapeURLPath(rel string) string {
+	segments := strings.Split(rel, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
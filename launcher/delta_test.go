@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCheckFileListLimits(t *testing.T) {
+	limits := ExtractLimits{MaxTotalSize: 100, MaxFileSize: 60, MaxEntries: 3, MaxRatio: 10}
+	file := func(path string, size int64) ManifestFile {
+		return ManifestFile{Path: path, Size: size}
+	}
+	tests := []struct {
+		name  string
+		files []ManifestFile
+		ok    bool
+	}{
+		{"within limits", []ManifestFile{file("a", 60), file("b", 40)}, true},
+		{"too many entries", []ManifestFile{file("a", 1), file("b", 1), file("c", 1), file("d", 1)}, false},
+		{"file too large", []ManifestFile{file("a", 61)}, false},
+		{"negative size", []ManifestFile{file("a", -1)}, false},
+		{"total too large", []ManifestFile{file("a", 60), file("b", 41)}, false},
+	}
+	for _, tt := range tests {
+		err := checkFileList(&InstallManifest{Version: "1.0.0", Files: tt.files}, limits)
+		if tt.ok && err != nil {
+			t.Errorf("%s: checkFileList() error = %v", tt.name, err)
+		}
+		if !tt.ok && !errors.Is(err, errUnsafeArchive) {
+			t.Errorf("%s: checkFileList() error = %v, want %v", tt.name, err, errUnsafeArchive)
+		}
+	}
+}
+
+func TestCopyVerifiedUsesListedModTime(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	content := []byte("unchanged")
+	if err := os.WriteFile(src, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	listed := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	file := ManifestFile{Path: "app/src.txt", Size: int64(len(content)), SHA256: sha256Hex(content), ModTime: listed}
+
+	dest := filepath.Join(dir, "dest.txt")
+	if err := copyVerified(src, dest, file); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(listed) {
+		t.Errorf("ModTime = %v, want %v from the file list", info.ModTime(), listed)
+	}
+}
+
+func TestRepairFromFileListFetchesOnlyBrokenFiles(t *testing.T) {
+	listed := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	content := map[string][]byte{"app/index.html": []byte("<html></html>"), "app/main.js": []byte("console.log(1)")}
+	list := InstallManifest{Version: "1.0.0"}
+	for _, rel := range []string{"app/index.html", "app/main.js"} {
+		list.Files = append(list.Files, ManifestFile{Path: rel, Size: int64(len(content[rel])), SHA256: sha256Hex(content[rel]), ModTime: listed})
+	}
+	listData, _ := json.Marshal(list)
+
+	var mu sync.Mutex
+	var requested []string
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/files/1.0.0/files.json":
+			w.Write(listData)
+		case "/files/1.0.0/app/main.js":
+			w.Write(content["app/main.js"])
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	installPath := t.TempDir()
+	stateMu.Lock()
+	oldConfig := config
+	config = defaultConfig()
+	config.InstallPath = installPath
+	stateMu.Unlock()
+	oldAllow := allowUnsigned
+	allowUnsigned = "1"
+	transportMu.Lock()
+	oldTransport := sharedTransport
+	sharedTransport = &userAgentTransport{ts.Client().Transport.(*http.Transport)}
+	transportMu.Unlock()
+	t.Cleanup(func() {
+		stateMu.Lock()
+		config = oldConfig
+		stateMu.Unlock()
+		allowUnsigned = oldAllow
+		transportMu.Lock()
+		sharedTransport = oldTransport
+		transportMu.Unlock()
+	})
+
+	dir := filepath.Join(installPath, "1.0.0", "app")
+	os.MkdirAll(dir, 0755)
+	os.WriteFile(filepath.Join(dir, "index.html"), content["app/index.html"], 0644)
+	os.WriteFile(filepath.Join(dir, "main.js"), []byte("tampered"), 0644)
+
+	info := &VersionInfo{Artifacts: map[string]ArtifactInfo{"1.0.0": {Files: ts.URL + "/files/1.0.0/files.json"}}}
+	if err := repairFromFileList(info, "1.0.0", map[string]bool{"app/main.js": true}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"/files/1.0.0/files.json", "/files/1.0.0/app/main.js"}; !reflect.DeepEqual(requested, want) {
+		t.Errorf("requested %v, want %v", requested, want)
+	}
+	path := filepath.Join(dir, "main.js")
+	if data, _ := os.ReadFile(path); string(data) != string(content["app/main.js"]) {
+		t.Errorf("main.js = %q after repair", data)
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stat.ModTime().Equal(listed) {
+		t.Errorf("main.js ModTime = %v, want %v from the file list", stat.ModTime(), listed)
+	}
+}
+
+func TestRepairFromFileListWithoutList(t *testing.T) {
+	info := &VersionInfo{Artifacts: map[string]ArtifactInfo{"1.0.0": {}}}
+	if err := repairFromFileList(info, "1.0.0", map[string]bool{"app/main.js": true}, nil); err != errNoDelta {
+		t.Errorf("repairFromFileList() error = %v, want %v", err, errNoDelta)
+	}
+}
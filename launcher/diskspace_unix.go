@@ -0,0 +1,23 @@
+//go:build unix
+
+/**
+ * LTTH Launcher - Disk Space and Path Limits (Unix)
+ */
+
+package main
+
+import "syscall"
+
+// freeDiskSpace returns the bytes available to the current user on the file system of path
+func freeDiskSpace(path string) (int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return int64(uint64(stat.Bavail) * uint64(stat.Bsize)), nil
+}
+
+// maxPathLength returns PATH_MAX
+func maxPathLength() int {
+	return 4095
+}
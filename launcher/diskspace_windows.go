@@ -0,0 +1,36 @@
+/**
+ * LTTH Launcher - Disk Space and Path Limits (Windows)
+ */
+
+package main
+
+import (
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+// freeDiskSpace returns the bytes available to the current user on the drive of path
+func freeDiskSpace(path string) (int64, error) {
+	dir, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var available, total, free uint64
+	if err := windows.GetDiskFreeSpaceEx(dir, &available, &total, &free); err != nil {
+		return 0, err
+	}
+	return int64(available), nil
+}
+
+// maxPathLength returns the longest usable path, which is MAX_PATH unless
+// long paths are enabled for the system
+func maxPathLength() int {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, `SYSTEM\CurrentControlSet\Control\FileSystem`, registry.QUERY_VALUE)
+	if err == nil {
+		defer key.Close()
+		if enabled, _, err := key.GetIntegerValue("LongPathsEnabled"); err == nil && enabled == 1 {
+			return 32767
+		}
+	}
+	return 259
+}
@@ -0,0 +1,417 @@
+/**
+ * LTTH Launcher - Download Engine
+ *
+ * Resumable downloads with HTTP Range/If-Range, retries with exponential
+ * backoff and jitter, and connect/idle timeouts.
+ *
+ * A running download is written to <file>.part; the validators needed to
+ * resume it (URL, ETag, Last-Modified, total size) live in <file>.part.json.
+ * The SHA256 of the file is computed while it is written, so verifying a
+ * download needs no second pass over it.
+ *
+ * Downloads can be throttled by a rateLimiter shared by all downloads of the
+ * same priority (see background.go).
+ */
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Download engine defaults; timeouts come from the network settings
+const (
+	downloadMaxRetries  = 6
+	downloadBaseBackoff = 1 * time.Second
+	downloadMaxBackoff  = 30 * time.Second
+)
+
+// partialMeta is stored next to a partial download
+type partialMeta struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Size         int64  `json:"size"`
+}
+
+// Downloader fetches files with resume and retry support
+type Downloader struct {
+	Client      *http.Client
+	MaxRetries  int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	IdleTimeout time.Duration
+	Progress    ProgressFunc
+	Limiter     *rateLimiter
+}
+
+// errDownloadInterrupted is returned when a rate limiter stops a download;
+// the partial file is kept so the download resumes next time
+var errDownloadInterrupted = errors.New("download interrupted")
+
+// permanentError marks failures that retrying will not fix
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// newDownloader creates a downloader with the default timeouts
+func newDownloader() *Downloader {
+	return &Downloader{
+		Client:      downloadClient(),
+		MaxRetries:  downloadMaxRetries,
+		BaseBackoff: downloadBaseBackoff,
+		MaxBackoff:  downloadMaxBackoff,
+		IdleTimeout: time.Duration(networkSettings().IdleTimeout) * time.Second,
+		Limiter:     currentLimiter(),
+	}
+}
+
+// Download fetches url into dest, resuming a previous partial download if
+// possible, and returns the SHA256 of the file
+func (d *Downloader) Download(ctx context.Context, dest, url string) (string, error) {
+	tracker := newProgressTracker(PhaseDownload, UnitBytes, -1, d.Progress)
+
+	var err error
+	for attempt := 0; attempt <= d.MaxRetries; attempt++ {
+		if attempt > 0 {
+			wait := d.backoff(attempt)
+			log.Printf("Download attempt %d failed: %v (retrying in %s)", attempt, err, wait.Round(time.Millisecond))
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		}
+
+		var sum string
+		sum, err = d.attempt(ctx, dest, url, tracker)
+		if err == nil {
+			if err := os.Rename(dest+".part", dest); err != nil {
+				return "", err
+			}
+			os.Remove(dest + ".part.json")
+			tracker.Finish()
+			return sum, nil
+		}
+
+		var perm *permanentError
+		if errors.As(err, &perm) || ctx.Err() != nil {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("giving up after %d retries: %v", d.MaxRetries, err)
+}
+
+// backoff returns the exponential delay for an attempt with jitter applied
+func (d *Downloader) backoff(attempt int) time.Duration {
+	delay := d.BaseBackoff << uint(attempt-1)
+	if delay <= 0 || delay > d.MaxBackoff {
+		delay = d.MaxBackoff
+	}
+	// Wait between half and the full delay so clients don't retry in lockstep
+	half := int64(delay / 2)
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// attempt performs a single request, continuing the partial file when the
+// server allows it, and returns the SHA256 of the complete file
+func (d *Downloader) attempt(ctx context.Context, dest, url string, tracker *progressTracker) (string, error) {
+	partPath := dest + ".part"
+	metaPath := dest + ".part.json"
+
+	meta, offset := loadPartial(partPath, metaPath, url)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", &permanentError{err}
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if meta.ETag != "" {
+			req.Header.Set("If-Range", meta.ETag)
+		} else {
+			req.Header.Set("If-Range", meta.LastModified)
+		}
+	}
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		start, total, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			os.Remove(partPath)
+			return "", fmt.Errorf("unexpected Content-Range: %q", resp.Header.Get("Content-Range"))
+		}
+		log.Printf("Resuming download at %d of %d bytes", offset, total)
+		meta.Size = total
+		flags |= os.O_APPEND
+	case http.StatusOK:
+		// Fresh download, or the file changed since the partial was written
+		if offset > 0 {
+			log.Printf("Server sent the full file, restarting download")
+		}
+		offset = 0
+		meta.Size = resp.ContentLength
+		flags |= os.O_TRUNC
+	case http.StatusRequestedRangeNotSatisfiable:
+		os.Remove(partPath)
+		os.Remove(metaPath)
+		return "", fmt.Errorf("bad status: %s", resp.Status)
+	default:
+		err := fmt.Errorf("bad status: %s", resp.Status)
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+			resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+			return "", &permanentError{err}
+		}
+		return "", err
+	}
+
+	meta.URL = url
+	if etag := resp.Header.Get("ETag"); etag != "" || flags&os.O_TRUNC != 0 {
+		meta.ETag = etag
+	}
+	if modified := resp.Header.Get("Last-Modified"); modified != "" || flags&os.O_TRUNC != 0 {
+		meta.LastModified = modified
+	}
+	savePartialMeta(metaPath, meta)
+
+	// A resumed download hashes the part already on disk first
+	hash := sha256.New()
+	if flags&os.O_APPEND != 0 {
+		if err := hashPrefix(hash, partPath, offset); err != nil {
+			os.Remove(partPath)
+			return "", err
+		}
+	}
+
+	out, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return "", &permanentError{err}
+	}
+
+	body := newIdleTimeoutReader(resp.Body, d.IdleTimeout, cancel)
+	defer body.Stop()
+
+	var src io.Reader = body
+	if d.Limiter != nil {
+		src = &throttledReader{ctx: ctx, r: body, limiter: d.Limiter}
+	}
+
+	tracker.Reset(offset, meta.Size)
+	written, err := io.Copy(io.MultiWriter(out, tracker, hash), src)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		if body.TimedOut() {
+			return "", fmt.Errorf("connection idle for %s", d.IdleTimeout)
+		}
+		return "", err
+	}
+
+	if meta.Size >= 0 && offset+written != meta.Size {
+		return "", io.ErrUnexpectedEOF
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// hashPrefix feeds the first n bytes of the file at path into w
+func hashPrefix(w io.Writer, path string, n int64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.CopyN(w, f, n)
+	return err
+}
+
+// loadPartial returns the stored metadata and resume offset of a partial download
+func loadPartial(partPath, metaPath, url string) (partialMeta, int64) {
+	var meta partialMeta
+	data, err := os.ReadFile(metaPath)
+	if err != nil || json.Unmarshal(data, &meta) != nil || meta.URL != url ||
+		(meta.ETag == "" && meta.LastModified == "") {
+		// No usable validator: a partial file can't be resumed safely
+		os.Remove(partPath)
+		return partialMeta{URL: url}, 0
+	}
+
+	info, err := os.Stat(partPath)
+	if err != nil || (meta.Size > 0 && info.Size() > meta.Size) {
+		os.Remove(partPath)
+		return meta, 0
+	}
+	return meta, info.Size()
+}
+
+// savePartialMeta writes the resume metadata of a partial download
+func savePartialMeta(metaPath string, meta partialMeta) {
+	data, _ := json.Marshal(meta)
+	if err := os.WriteFile(metaPath, data, 0644); err != nil {
+		log.Printf("Warning: could not save download state: %v", err)
+	}
+}
+
+// parseContentRange parses "bytes <start>-<end>/<total>"; total is -1 if unknown
+func parseContentRange(value string) (start, total int64, ok bool) {
+	value = strings.TrimPrefix(value, "bytes ")
+	rangePart, totalPart, found := strings.Cut(value, "/")
+	if !found {
+		return 0, 0, false
+	}
+	startPart, _, found := strings.Cut(rangePart, "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(startPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	if totalPart == "*" {
+		return start, -1, true
+	}
+	total, err = strconv.ParseInt(totalPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return start, total, true
+}
+
+// rateLimiter spreads reads over time so they stay below a rate. Rate and
+// stop condition are asked on every read, so changed settings apply at once.
+type rateLimiter struct {
+	mu   sync.Mutex
+	next time.Time
+	rate func() int64 // bytes per second, <= 0 for no limit
+	stop func() bool  // whether downloads have to stop now; may be nil
+}
+
+// chunk returns the most bytes to read at once, so a single read never has
+// to wait long enough to trip the idle timeout
+func (l *rateLimiter) chunk(max int) int {
+	rate := l.rate()
+	if rate <= 0 {
+		return max
+	}
+	n := int(rate / 8)
+	if n < 1024 {
+		n = 1024
+	}
+	if n > max {
+		n = max
+	}
+	return n
+}
+
+// wait blocks until n more bytes fit into the rate
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	if l.stop != nil && l.stop() {
+		return &permanentError{errDownloadInterrupted}
+	}
+	rate := l.rate()
+	if rate <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(time.Duration(int64(n) * int64(time.Second) / rate))
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// throttledReader reads through a rateLimiter
+type throttledReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rateLimiter
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p[:t.limiter.chunk(len(p))])
+	if n > 0 {
+		if waitErr := t.limiter.wait(t.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// idleTimeoutReader cancels the request when no data arrives for the timeout
+type idleTimeoutReader struct {
+	r        io.Reader
+	timeout  time.Duration
+	timer    *time.Timer
+	once     sync.Once
+	timedOut chan struct{}
+}
+
+func newIdleTimeoutReader(r io.Reader, timeout time.Duration, cancel context.CancelFunc) *idleTimeoutReader {
+	t := &idleTimeoutReader{r: r, timeout: timeout, timedOut: make(chan struct{})}
+	t.timer = time.AfterFunc(timeout, func() {
+		t.once.Do(func() { close(t.timedOut) })
+		cancel()
+	})
+	return t
+}
+
+func (t *idleTimeoutReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	if n > 0 {
+		t.timer.Reset(t.timeout)
+	}
+	return n, err
+}
+
+// Stop releases the idle timer
+func (t *idleTimeoutReader) Stop() {
+	t.timer.Stop()
+}
+
+// TimedOut reports whether the reader was cancelled for being idle
+func (t *idleTimeoutReader) TimedOut() bool {
+	select {
+	case <-t.timedOut:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// rangeServer serves content with Range/If-Range support. It can cut the
+// first response off mid-stream and change the content at that point.
+type rangeServer struct {
+	mu          sync.Mutex
+	content     []byte
+	etag        string
+	dropAfter   int
+	changeTo    []byte
+	changeETag  string
+	ignoreRange bool
+	requests    []http.Header
+}
+
+func (s *rangeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Header.Clone())
+	content, etag, drop := s.content, s.etag, s.dropAfter
+	s.dropAfter = 0
+	if drop > 0 && s.changeTo != nil {
+		s.content, s.etag = s.changeTo, s.changeETag
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("ETag", etag)
+	if drop > 0 {
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.WriteHeader(http.StatusOK)
+		w.Write(content[:drop])
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}
+	if s.ignoreRange {
+		r.Header.Del("Range")
+	}
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+}
+
+func testContent(size int, seed int64) []byte {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(seed)).Read(data)
+	return data
+}
+
+func testDownloader(client *http.Client) *Downloader {
+	return &Downloader{
+		Client:      client,
+		MaxRetries:  3,
+		BaseBackoff: time.Millisecond,
+		MaxBackoff:  time.Millisecond,
+		IdleTimeout: 5 * time.Second,
+	}
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// checkDownloaded verifies the downloaded file, its reported SHA256 and that
+// no resume state is left behind
+func checkDownloaded(t *testing.T, dest, sum string, want []byte) {
+	t.Helper()
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("downloaded %d bytes, want the %d bytes served", len(got), len(want))
+	}
+	if sum != sha256Hex(want) {
+		t.Errorf("SHA256 = %s, want %s", sum, sha256Hex(want))
+	}
+	for _, leftover := range []string{dest + ".part", dest + ".part.json"} {
+		if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+			t.Errorf("%s left behind", filepath.Base(leftover))
+		}
+	}
+}
+
+func TestDownloadResumesAfterDroppedConnection(t *testing.T) {
+	content := testContent(256<<10, 1)
+	server := &rangeServer{content: content, etag: `"v1"`, dropAfter: 100 << 10}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	dest := filepath.Join(t.TempDir(), "ltth.zip")
+	sum, err := testDownloader(ts.Client()).Download(context.Background(), dest, ts.URL+"/ltth.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkDownloaded(t, dest, sum, content)
+
+	if len(server.requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(server.requests))
+	}
+	resumed := server.requests[1]
+	if got, want := resumed.Get("Range"), "bytes=102400-"; got != want {
+		t.Errorf("Range = %q, want %q", got, want)
+	}
+	if got := resumed.Get("If-Range"); got != `"v1"` {
+		t.Errorf("If-Range = %q, want the ETag of the first response", got)
+	}
+}
+
+func TestDownloadRestartsOnFullResponse(t *testing.T) {
+	content := testContent(256<<10, 2)
+	server := &rangeServer{content: content, etag: `"v1"`, dropAfter: 100 << 10, ignoreRange: true}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	dest := filepath.Join(t.TempDir(), "ltth.zip")
+	sum, err := testDownloader(ts.Client()).Download(context.Background(), dest, ts.URL+"/ltth.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A 200 to a Range request must replace the partial file, not extend it
+	checkDownloaded(t, dest, sum, content)
+}
+
+func TestDownloadRestartsWhenETagChanged(t *testing.T) {
+	old := testContent(256<<10, 3)
+	changed := testContent(200<<10, 4)
+	server := &rangeServer{content: old, etag: `"v1"`, dropAfter: 100 << 10, changeTo: changed, changeETag: `"v2"`}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	dest := filepath.Join(t.TempDir(), "ltth.zip")
+	sum, err := testDownloader(ts.Client()).Download(context.Background(), dest, ts.URL+"/ltth.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// If-Range no longer matches, so the server sends the new file in full
+	checkDownloaded(t, dest, sum, changed)
+	if got := server.requests[1].Get("If-Range"); got != `"v1"` {
+		t.Errorf("If-Range = %q, want the old ETag", got)
+	}
+}
+
+func TestDownloadResumesPartialFromEarlierRun(t *testing.T) {
+	content := testContent(128<<10, 5)
+	server := &rangeServer{content: content, etag: `"v1"`}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	url := ts.URL + "/ltth.zip"
+	dest := filepath.Join(t.TempDir(), "ltth.zip")
+	if err := os.WriteFile(dest+".part", content[:50000], 0644); err != nil {
+		t.Fatal(err)
+	}
+	savePartialMeta(dest+".part.json", partialMeta{URL: url, ETag: `"v1"`, Size: int64(len(content))})
+
+	sum, err := testDownloader(ts.Client()).Download(context.Background(), dest, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkDownloaded(t, dest, sum, content)
+	if got := server.requests[0].Get("Range"); got != "bytes=50000-" {
+		t.Errorf("Range = %q, want bytes=50000-", got)
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		value        string
+		start, total int64
+		ok           bool
+	}{
+		{"bytes 100-199/200", 100, 200, true},
+		{"bytes 0-99/*", 0, -1, true},
+		{"bytes 100-199", 0, 0, false},
+		{"bytes x-199/200", 0, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, tt := range tests {
+		start, total, ok := parseContentRange(tt.value)
+		if start != tt.start || total != tt.total || ok != tt.ok {
+			t.Errorf("parseContentRange(%q) = %d, %d, %v; want %d, %d, %v", tt.value, start, total, ok, tt.start, tt.total, tt.ok)
+		}
+	}
+}
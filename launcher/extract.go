@@ -0,0 +1,398 @@
+/**
+ * LTTH Launcher - Archive Extraction
+ *
+ * Release archives are ZIP files or, for Linux and macOS builds, tar.gz or
+ * tar.zst tarballs; the extractor is chosen by artifacts.<version>.mediaType
+ * or else by the file extension of the archive URL.
+ *
+ * Archives are checked before and while they are unpacked: entry count,
+ * total and per-file size and compression ratio are limited (config
+ * extractLimits) and only regular files and folders (and, in tarballs,
+ * symbolic links within the archive) are accepted.
+ *
+ * Files keep their permissions within safePermMask and their modification
+ * time, so start scripts stay executable and mtime based tools (npm,
+ * bundlers) see the release's timestamps. Folders are always 0755.
+ */
+
+package main
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Archive media types as published in artifacts.<version>.mediaType
+const (
+	mediaTypeZip    = "application/zip"
+	mediaTypeTarGz  = "application/x-tar+gzip"
+	mediaTypeTarZst = "application/x-tar+zstd"
+)
+
+// archiveExtractor extracts the files whose slash-separated path is in only,
+// or every file if only is nil, and returns size and hash of each written file
+type archiveExtractor func(src, dest string, only map[string]bool, report ProgressFunc) ([]ManifestFile, error)
+
+// archiveExtractors maps each supported media type to its extractor
+var archiveExtractors = map[string]archiveExtractor{
+	mediaTypeZip:    extractZipFiles,
+	mediaTypeTarGz:  extractTarGzFiles,
+	mediaTypeTarZst: extractTarZstFiles,
+}
+
+// archiveExtensions are the file extensions of each media type
+var archiveExtensions = map[string]string{
+	mediaTypeZip:    ".zip",
+	mediaTypeTarGz:  ".tar.gz",
+	mediaTypeTarZst: ".tar.zst",
+}
+
+// errUnsafeArchive marks archives rejected by the extraction limits or checks
+var errUnsafeArchive = errors.New("unsafe archive")
+
+// ExtractLimits bounds what a single archive may unpack to
+type ExtractLimits struct {
+	MaxTotalSize int64 `json:"maxTotalSize"`
+	MaxFileSize  int64 `json:"maxFileSize"`
+	MaxEntries   int   `json:"maxEntries"`
+	MaxRatio     int64 `json:"maxRatio"`
+}
+
+// safePermMask limits restored permissions: no setuid, setgid or sticky bits
+// and nothing writable by group or others
+const safePermMask = 0755
+
+// maxExtractWorkers caps the number of files written in parallel; a variable
+// so benchmarks can compare worker counts
+var maxExtractWorkers = 8
+
+// ratioMinSize is the size below which the compression ratio isn't checked;
+// small files of repeated text legitimately compress very well
+const ratioMinSize = 1 << 20
+
+// defaultExtractLimits fit the largest releases with plenty of headroom
+func defaultExtractLimits() ExtractLimits {
+	return ExtractLimits{
+		MaxTotalSize: 8 << 30,
+		MaxFileSize:  2 << 30,
+		MaxEntries:   250000,
+		MaxRatio:     200,
+	}
+}
+
+// extractLimits returns the configured limits, using defaults for unset values
+func extractLimits() ExtractLimits {
+	limits, defaults := currentConfig().ExtractLimits, defaultExtractLimits()
+	if limits.MaxTotalSize <= 0 {
+		limits.MaxTotalSize = defaults.MaxTotalSize
+	}
+	if limits.MaxFileSize <= 0 {
+		limits.MaxFileSize = defaults.MaxFileSize
+	}
+	if limits.MaxEntries <= 0 {
+		limits.MaxEntries = defaults.MaxEntries
+	}
+	if limits.MaxRatio <= 0 {
+		limits.MaxRatio = defaults.MaxRatio
+	}
+	return limits
+}
+
+// unsafeArchive returns an error wrapping errUnsafeArchive
+func unsafeArchive(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", errUnsafeArchive, fmt.Sprintf(format, args...))
+}
+
+// archiveMediaType returns the media type of the archive of version, derived
+// from its URL if version.json doesn't state it
+func archiveMediaType(info *VersionInfo, version string) string {
+	if mediaType := info.Artifacts[version].MediaType; mediaType != "" {
+		return mediaType
+	}
+	return mediaTypeFromName(artifactURL(info, version))
+}
+
+// mediaTypeFromName guesses the media type from a file name or URL, defaulting to ZIP
+func mediaTypeFromName(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz"):
+		return mediaTypeTarGz
+	case strings.HasSuffix(name, ".tar.zst") || strings.HasSuffix(name, ".tzst"):
+		return mediaTypeTarZst
+	}
+	return mediaTypeZip
+}
+
+// extractArchive extracts an archive of the given media type; only selects
+// files as for archiveExtractor
+func extractArchive(src, dest, mediaType string, only map[string]bool, report ProgressFunc) ([]ManifestFile, error) {
+	extract, ok := archiveExtractors[mediaType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported archive type %q", mediaType)
+	}
+	return extract(src, dest, only, report)
+}
+
+// extractZipFiles extracts the files whose slash-separated path is in only,
+// or every file if only is nil. Files are written by extractWorkers in
+// parallel and hashed while they are written.
+func extractZipFiles(src, dest string, only map[string]bool, report ProgressFunc) ([]ManifestFile, error) {
+	r, err := zip.OpenReader(src)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	selected := func(f *zip.File) bool {
+		return only == nil || only[filepath.ToSlash(filepath.Clean(f.Name))]
+	}
+
+	// Check the whole archive before writing anything
+	limits := extractLimits()
+	if len(r.File) > limits.MaxEntries {
+		return nil, unsafeArchive("%d entries exceed the limit of %d", len(r.File), limits.MaxEntries)
+	}
+	var files []*zip.File
+	declared := uint64(0)
+	seen := make(map[string]bool, len(r.File))
+	for _, f := range r.File {
+		if err := checkZipEntry(f, limits); err != nil {
+			return nil, err
+		}
+		name := filepath.ToSlash(filepath.Clean(f.Name))
+		if seen[name] {
+			return nil, unsafeArchive("duplicate entry: %s", f.Name)
+		}
+		seen[name] = true
+		if !f.FileInfo().IsDir() && selected(f) {
+			files = append(files, f)
+			declared += f.UncompressedSize64
+		}
+	}
+	if declared > uint64(limits.MaxTotalSize) {
+		return nil, unsafeArchive("unpacked size of %d bytes exceeds the limit of %d", declared, limits.MaxTotalSize)
+	}
+	progress := newProgressTracker(PhaseExtract, UnitFiles, int64(len(files)), report)
+
+	// Clean and normalize destination path
+	cleanDest := filepath.Clean(dest)
+	os.MkdirAll(cleanDest, 0755)
+
+	// Create the folders up front so workers only write files
+	var dirs []extractedDir
+	for _, f := range r.File {
+		fpath := filepath.Join(cleanDest, filepath.Clean(filepath.FromSlash(f.Name)))
+		if f.FileInfo().IsDir() {
+			if only == nil {
+				os.MkdirAll(fpath, 0755)
+				dirs = append(dirs, extractedDir{fpath, f.Modified})
+			}
+		} else if selected(f) {
+			if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Each file is limited to its declared size, so together they stay
+	// within the total checked above
+	written := make([]ManifestFile, len(files))
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	jobs := make(chan int)
+	for w := 0; w < extractWorkers(len(files)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				f := files[i]
+				fpath := filepath.Join(cleanDest, filepath.Clean(filepath.FromSlash(f.Name)))
+				file, err := extractZipEntry(f, fpath, limits)
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					continue
+				}
+				written[i] = file
+				progress.Add(1)
+			}
+		}()
+	}
+	for i := range files {
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	restoreDirTimes(dirs)
+
+	progress.Finish()
+	return written, nil
+}
+
+// extractWorkers returns how many files are written in parallel
+func extractWorkers(files int) int {
+	workers := runtime.NumCPU()
+	if workers > maxExtractWorkers {
+		workers = maxExtractWorkers
+	}
+	if workers > files {
+		workers = files
+	}
+	return workers
+}
+
+// checkZipEntry rejects entries with unsafe paths, types, sizes or ratios
+func checkZipEntry(f *zip.File, limits ExtractLimits) error {
+	if err := checkEntry(f.Name, f.Mode(), f.UncompressedSize64, limits); err != nil {
+		return err
+	}
+	if f.Mode().IsRegular() {
+		return checkRatio(f.Name, f.UncompressedSize64, f.CompressedSize64, limits)
+	}
+	return nil
+}
+
+// checkEntry rejects entries with unsafe paths, types or sizes
+func checkEntry(name string, mode os.FileMode, size uint64, limits ExtractLimits) error {
+	if !filepath.IsLocal(filepath.Clean(filepath.FromSlash(name))) {
+		return unsafeArchive("invalid file path: %s", name)
+	}
+
+	switch {
+	case mode&os.ModeSymlink != 0:
+		return unsafeArchive("symbolic link: %s", name)
+	case mode.IsDir():
+		return nil
+	case !mode.IsRegular():
+		return unsafeArchive("not a regular file: %s (%s)", name, mode.Type())
+	}
+
+	if size > uint64(limits.MaxFileSize) {
+		return unsafeArchive("%s: %d bytes exceed the file size limit of %d", name, size, limits.MaxFileSize)
+	}
+	return nil
+}
+
+// checkRatio rejects content that unpacks to more than MaxRatio times its
+// compressed size
+func checkRatio(name string, unpacked, compressed uint64, limits ExtractLimits) error {
+	if unpacked < ratioMinSize {
+		return nil
+	}
+	if compressed == 0 {
+		compressed = 1
+	}
+	if ratio := unpacked / compressed; ratio > uint64(limits.MaxRatio) {
+		return unsafeArchive("%s: compression ratio %d exceeds the limit of %d", name, ratio, limits.MaxRatio)
+	}
+	return nil
+}
+
+// extractZipEntry writes one regular file of a ZIP archive
+func extractZipEntry(f *zip.File, fpath string, limits ExtractLimits) (ManifestFile, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return ManifestFile{}, err
+	}
+	defer rc.Close()
+	return writeEntry(rc, f.Name, fpath, f.Mode(), f.Modified, int64(f.UncompressedSize64), limits, limits.MaxTotalSize)
+}
+
+// writeEntry writes and hashes one regular file, stopping once it grows
+// beyond its declared size, the file size limit or the remaining total.
+// Permissions and modification time are restored afterwards. The file is
+// written next to fpath and renamed over it, so a hardlink into the shared
+// store is replaced instead of written through.
+func writeEntry(r io.Reader, name, fpath string, mode os.FileMode, modTime time.Time, declared int64, limits ExtractLimits, remaining int64) (ManifestFile, error) {
+	limit := limits.MaxFileSize
+	if remaining < limit {
+		limit = remaining
+	}
+	if declared < limit {
+		limit = declared
+	}
+
+	outFile, err := os.CreateTemp(filepath.Dir(fpath), "."+filepath.Base(fpath)+".*")
+	if err != nil {
+		return ManifestFile{}, err
+	}
+	tmp := outFile.Name()
+	hash := sha256.New()
+	n, err := io.Copy(io.MultiWriter(outFile, hash), io.LimitReader(r, limit+1))
+	if closeErr := outFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && n > limit {
+		err = unsafeArchive("%s is larger than declared or allowed", name)
+	}
+	if err == nil {
+		// CreateTemp always creates the file with 0600
+		err = os.Chmod(tmp, restoredMode(mode))
+	}
+	if err == nil {
+		err = os.Rename(tmp, fpath)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return ManifestFile{}, err
+	}
+	if !modTime.IsZero() {
+		os.Chtimes(fpath, modTime, modTime)
+	}
+	return ManifestFile{
+		Path:       path.Clean(filepath.ToSlash(name)),
+		Size:       n,
+		SHA256:     hex.EncodeToString(hash.Sum(nil)),
+		Executable: restoredMode(mode)&0111 != 0,
+		ModTime:    modTime,
+	}, nil
+}
+
+// restoredMode returns the permissions of an extracted file: those from the
+// archive within safePermMask, always readable and writable by the owner
+func restoredMode(mode os.FileMode) os.FileMode {
+	return mode.Perm()&safePermMask | 0600
+}
+
+// extractedDir is a folder whose modification time is restored once all of
+// its content has been written
+type extractedDir struct {
+	path    string
+	modTime time.Time
+}
+
+// restoreDirTimes sets the modification times of extracted folders
+func restoreDirTimes(dirs []extractedDir) {
+	for _, dir := range dirs {
+		if !dir.modTime.IsZero() {
+			os.Chtimes(dir.path, dir.modTime, dir.modTime)
+		}
+	}
+}
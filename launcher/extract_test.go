@@ -0,0 +1,278 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// testEntry is one entry of an archive built by writeTestArchive
+type testEntry struct {
+	name string
+	body []byte
+	link string // symbolic link target
+	dir  bool
+}
+
+// testFormats are the media types every extraction test runs against
+var testFormats = []string{mediaTypeZip, mediaTypeTarGz, mediaTypeTarZst}
+
+// writeTestArchive writes entries as an archive of mediaType into dir
+func writeTestArchive(t testing.TB, dir, mediaType string, entries []testEntry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	var err error
+	switch mediaType {
+	case mediaTypeZip:
+		err = writeTestZip(&buf, entries)
+	case mediaTypeTarGz:
+		gz := gzip.NewWriter(&buf)
+		if err = writeTestTar(gz, entries); err == nil {
+			err = gz.Close()
+		}
+	case mediaTypeTarZst:
+		var zw *zstd.Encoder
+		if zw, err = zstd.NewWriter(&buf); err == nil {
+			if err = writeTestTar(zw, entries); err == nil {
+				err = zw.Close()
+			}
+		}
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(dir, "release"+archiveExtensions[mediaType])
+	if err := os.WriteFile(archive, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return archive
+}
+
+func writeTestZip(w io.Writer, entries []testEntry) error {
+	zw := zip.NewWriter(w)
+	for _, e := range entries {
+		hdr := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		body := e.body
+		switch {
+		case e.dir:
+			hdr.Name = strings.TrimSuffix(e.name, "/") + "/"
+			hdr.SetMode(os.ModeDir | 0755)
+		case e.link != "":
+			hdr.SetMode(os.ModeSymlink | 0777)
+			body = []byte(e.link)
+		default:
+			hdr.SetMode(0644)
+		}
+		fw, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(body); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func writeTestTar(w io.Writer, entries []testEntry) error {
+	tw := tar.NewWriter(w)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: 0644, Typeflag: tar.TypeReg}
+		body := e.body
+		switch {
+		case e.dir:
+			hdr.Typeflag, hdr.Mode, body = tar.TypeDir, 0755, nil
+		case e.link != "":
+			hdr.Typeflag, hdr.Linkname, hdr.Mode, body = tar.TypeSymlink, e.link, 0777, nil
+		}
+		hdr.Size = int64(len(body))
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(body); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// setExtractLimits overrides the configured extraction limits for one test
+func setExtractLimits(t testing.TB, limits ExtractLimits) {
+	stateMu.Lock()
+	old := config.ExtractLimits
+	config.ExtractLimits = limits
+	stateMu.Unlock()
+	t.Cleanup(func() {
+		stateMu.Lock()
+		config.ExtractLimits = old
+		stateMu.Unlock()
+	})
+}
+
+// checkNothingEscaped fails if anything but the archive was written outside dest
+func checkNothingEscaped(t *testing.T, root, dest, archive string) {
+	t.Helper()
+	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || p == archive {
+			return nil
+		}
+		if rel, err := filepath.Rel(dest, p); err != nil || !filepath.IsLocal(rel) {
+			t.Errorf("%s written outside the destination", p)
+		}
+		return nil
+	})
+}
+
+func TestExtractValidArchive(t *testing.T) {
+	entries := []testEntry{
+		{name: "app/", dir: true},
+		{name: "app/server.js", body: []byte("console.log('ok')")},
+		{name: "package.json", body: []byte("{}")},
+	}
+	for _, format := range testFormats {
+		t.Run(archiveExtensions[format], func(t *testing.T) {
+			root := t.TempDir()
+			archive := writeTestArchive(t, root, format, entries)
+			dest := filepath.Join(root, "versions", "1.0.0")
+
+			files, err := extractArchive(archive, dest, format, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != 2 {
+				t.Fatalf("extracted %d files, want 2", len(files))
+			}
+			got, err := os.ReadFile(filepath.Join(dest, "app", "server.js"))
+			if err != nil || string(got) != "console.log('ok')" {
+				t.Errorf("app/server.js = %q, %v", got, err)
+			}
+		})
+	}
+}
+
+func TestExtractRejectsMaliciousArchives(t *testing.T) {
+	zeros := make([]byte, 4<<20)
+	payload := []byte("pwned")
+	many := make([]testEntry, 11)
+	for i := range many {
+		many[i] = testEntry{name: "f" + string(rune('a'+i)), body: payload}
+	}
+
+	tests := []struct {
+		name        string
+		entries     []testEntry
+		limits      ExtractLimits
+		windowsOnly bool
+	}{
+		{name: "parent traversal", entries: []testEntry{{name: "../evil.txt", body: payload}}},
+		{name: "nested traversal", entries: []testEntry{{name: "app/../../evil.txt", body: payload}}},
+		{name: "absolute path", entries: []testEntry{{name: "/tmp/evil.txt", body: payload}}},
+		{name: "backslash traversal", entries: []testEntry{{name: `..\evil.txt`, body: payload}}, windowsOnly: true},
+		{name: "drive path", entries: []testEntry{{name: "C:/evil.txt", body: payload}}, windowsOnly: true},
+		{name: "drive relative path", entries: []testEntry{{name: "C:evil.txt", body: payload}}, windowsOnly: true},
+		{name: "UNC path", entries: []testEntry{{name: `\\host\share\evil.txt`, body: payload}}, windowsOnly: true},
+		{name: "symlink to parent", entries: []testEntry{{name: "lib", link: "../.."}}},
+		{name: "symlink to absolute path", entries: []testEntry{{name: "lib", link: "/etc"}}},
+		{name: "symlink outside the archive", entries: []testEntry{{name: "app/lib", link: "../../outside"}}},
+		{name: "symlink through symlink", entries: []testEntry{
+			{name: "app/", dir: true},
+			{name: "up", link: "app"},
+			{name: "escape", link: "up/../.."},
+		}},
+		{name: "symlink with absolute name", entries: []testEntry{{name: "/tmp/lib", link: "app"}}},
+		{name: "oversized file", entries: []testEntry{{name: "big.bin", body: make([]byte, 2048)}},
+			limits: ExtractLimits{MaxFileSize: 1024}},
+		{name: "oversized total", entries: []testEntry{
+			{name: "a.bin", body: make([]byte, 2000)},
+			{name: "b.bin", body: make([]byte, 2000)},
+		}, limits: ExtractLimits{MaxTotalSize: 3000}},
+		{name: "compression ratio bomb", entries: []testEntry{{name: "zeros.bin", body: zeros}}},
+		{name: "too many entries", entries: many, limits: ExtractLimits{MaxEntries: 10}},
+	}
+
+	for _, tt := range tests {
+		for _, format := range testFormats {
+			t.Run(tt.name+"/"+archiveExtensions[format], func(t *testing.T) {
+				if tt.windowsOnly && runtime.GOOS != "windows" {
+					t.Skip("only a special path on Windows")
+				}
+				setExtractLimits(t, tt.limits)
+				root := t.TempDir()
+				archive := writeTestArchive(t, root, format, tt.entries)
+				dest := filepath.Join(root, "versions", "1.0.0")
+
+				_, err := extractArchive(archive, dest, format, nil, nil)
+				if !errors.Is(err, errUnsafeArchive) {
+					t.Fatalf("extractArchive() error = %v, want %v", err, errUnsafeArchive)
+				}
+				checkNothingEscaped(t, root, dest, archive)
+			})
+		}
+	}
+}
+
+// smallFilesArchive writes an archive of thousands of small files, shaped
+// like a node_modules tree
+func smallFilesArchive(b *testing.B, mediaType string) string {
+	entries := make([]testEntry, 5000)
+	for i := range entries {
+		body := bytes.Repeat([]byte(fmt.Sprintf("module.exports = %d;\n", i)), 100)
+		entries[i] = testEntry{name: fmt.Sprintf("node_modules/pkg%03d/file%d.js", i/50, i), body: body}
+	}
+	return writeTestArchive(b, b.TempDir(), mediaType, entries)
+}
+
+// benchmarkExtract extracts archive into a fresh folder per iteration
+func benchmarkExtract(b *testing.B, archive, mediaType string) {
+	dest := b.TempDir()
+	for i := 0; i < b.N; i++ {
+		out := filepath.Join(dest, strconv.Itoa(i))
+		if _, err := extractArchive(archive, out, mediaType, nil, nil); err != nil {
+			b.Fatal(err)
+		}
+		b.StopTimer()
+		os.RemoveAll(out)
+		b.StartTimer()
+	}
+}
+
+// BenchmarkExtractZip compares writing ZIP entries with a single worker to
+// the default number of parallel workers
+func BenchmarkExtractZip(b *testing.B) {
+	archive := smallFilesArchive(b, mediaTypeZip)
+	counts := []int{1}
+	if workers := extractWorkers(math.MaxInt); workers > 1 {
+		counts = append(counts, workers)
+	}
+	for _, workers := range counts {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			defer func(old int) { maxExtractWorkers = old }(maxExtractWorkers)
+			maxExtractWorkers = workers
+			benchmarkExtract(b, archive, mediaTypeZip)
+		})
+	}
+}
+
+// Tarballs are a single stream and always extracted by one worker
+
+func BenchmarkExtractTarGz(b *testing.B) {
+	benchmarkExtract(b, smallFilesArchive(b, mediaTypeTarGz), mediaTypeTarGz)
+}
+
+func BenchmarkExtractTarZst(b *testing.B) {
+	benchmarkExtract(b, smallFilesArchive(b, mediaTypeTarZst), mediaTypeTarZst)
+}
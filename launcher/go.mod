@@ -2,9 +2,10 @@ module ltth-launcher
 
 go 1.21
 
-require github.com/jchv/go-webview2 v0.0.0-20221223143126-dc24628cff85
-
 require (
-	github.com/jchv/go-winloader v0.0.0-20200815041850-dec1ee9a7fd5 // indirect
-	golang.org/x/sys v0.0.0-20210218145245-beda7e5e158e // indirect
+	github.com/jchv/go-webview2 v0.0.0-20221223143126-dc24628cff85
+	github.com/klauspost/compress v1.17.11
+	golang.org/x/sys v0.0.0-20210218145245-beda7e5e158e
 )
+
+require github.com/jchv/go-winloader v0.0.0-20200815041850-dec1ee9a7fd5 // indirect
@@ -0,0 +1,183 @@
+/**
+ * LTTH Launcher - Staged Installs
+ *
+ * Archives are extracted into InstallPath/.staging, validated and then moved
+ * into InstallPath/<version> with a single rename. A completed version
+ * carries a file manifest and an install marker; folders without a marker
+ * are never offered.
+ *
+ * Leftovers of interrupted installs are swept on startup.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Installation layout below InstallPath
+const (
+	stagingDirName    = ".staging"
+	tempDirName       = ".temp"
+	installMarkerName = ".ltth-install.json"
+)
+
+// staleDownloadAge is how long partial downloads are kept for resuming
+const staleDownloadAge = 7 * 24 * time.Hour
+
+// launchableFiles are the entry points launchApp looks for, in order
+var launchableFiles = []string{"index.html", "launch.js"}
+
+// InstallMarker is written into a version folder once it is complete
+type InstallMarker struct {
+	Version     string    `json:"version"`
+	InstalledAt time.Time `json:"installedAt"`
+}
+
+// installVersionDir extracts an archive into staging, validates it and moves it into place
+func installVersionDir(info *VersionInfo, zipPath, version string, report ProgressFunc) error {
+	staging, err := stageArchive(zipPath, version, archiveMediaType(info, version), report)
+	if err != nil {
+		return err
+	}
+	if err := commitStaging(staging, filepath.Join(currentConfig().InstallPath, version)); err != nil {
+		os.RemoveAll(staging)
+		return err
+	}
+	return nil
+}
+
+// newStagingDir creates an empty staging folder for version
+func newStagingDir(version string) (string, error) {
+	stagingRoot := filepath.Join(currentConfig().InstallPath, stagingDirName)
+	if err := os.MkdirAll(stagingRoot, 0755); err != nil {
+		return "", err
+	}
+	return os.MkdirTemp(stagingRoot, version+"-")
+}
+
+// stageArchive extracts an archive of the given media type into a new staging
+// folder and finishes it
+func stageArchive(zipPath, version, mediaType string, report ProgressFunc) (string, error) {
+	staging, err := newStagingDir(version)
+	if err != nil {
+		return "", err
+	}
+	hashed, err := extractArchive(zipPath, staging, mediaType, nil, report)
+	if err != nil {
+		os.RemoveAll(staging)
+		return "", fmt.Errorf("extraction failed: %w", err)
+	}
+	if err := writeInstallManifest(staging, version, hashed, report); err != nil {
+		os.RemoveAll(staging)
+		return "", err
+	}
+	if err := finishStaging(staging, version); err != nil {
+		os.RemoveAll(staging)
+		return "", err
+	}
+	return staging, nil
+}
+
+// finishStaging validates a populated staging folder and marks it complete
+func finishStaging(staging, version string) error {
+	if !hasLaunchableFile(staging) {
+		return fmt.Errorf("archive contains none of %s", strings.Join(launchableFiles, ", "))
+	}
+	if err := linkIntoStore(staging); err != nil {
+		return err
+	}
+	return writeInstallMarker(staging, version)
+}
+
+// commitStaging renames a validated staging folder to its final place,
+// replacing an existing folder only once the new one is in position
+func commitStaging(staging, dest string) error {
+	var previous string
+	if _, err := os.Stat(dest); err == nil {
+		previous = staging + ".previous"
+		if err := os.Rename(dest, previous); err != nil {
+			return fmt.Errorf("could not replace %s: %v", dest, err)
+		}
+	}
+
+	if err := os.Rename(staging, dest); err != nil {
+		if previous != "" {
+			os.Rename(previous, dest)
+		}
+		return err
+	}
+
+	if previous != "" {
+		if err := os.RemoveAll(previous); err != nil {
+			log.Printf("Warning: could not remove replaced folder: %v", err)
+		}
+	}
+	return nil
+}
+
+// writeInstallMarker marks dir as a complete installation of version
+func writeInstallMarker(dir, version string) error {
+	data, _ := json.MarshalIndent(InstallMarker{Version: version, InstalledAt: time.Now()}, "", "  ")
+	return os.WriteFile(filepath.Join(dir, installMarkerName), data, 0644)
+}
+
+// isInstallComplete reports whether dir holds a usable installation. Folders
+// from launchers without markers are accepted if they contain an entry point.
+func isInstallComplete(dir string) bool {
+	if _, err := os.Stat(filepath.Join(dir, installMarkerName)); err == nil {
+		return true
+	}
+	return hasLaunchableFile(dir)
+}
+
+// hasLaunchableFile reports whether dir contains one of the launchable files
+func hasLaunchableFile(dir string) bool {
+	for _, name := range launchableFiles {
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil && !info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+// sweepInstallLeftovers removes staging folders of interrupted installs and
+// temporary downloads that are too old to be resumed
+func sweepInstallLeftovers() {
+	installPath := currentConfig().InstallPath
+	if installPath == "" {
+		return
+	}
+
+	staging := filepath.Join(installPath, stagingDirName)
+	if _, err := os.Stat(staging); err == nil {
+		log.Printf("Removing leftovers of an interrupted install")
+		if err := os.RemoveAll(staging); err != nil {
+			log.Printf("Warning: could not remove %s: %v", staging, err)
+		}
+	}
+
+	tempDir := filepath.Join(installPath, tempDirName)
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		// Keep recent partial downloads so they can still be resumed
+		resumable := strings.HasSuffix(entry.Name(), ".part") || strings.HasSuffix(entry.Name(), ".part.json")
+		if resumable && time.Since(info.ModTime()) < staleDownloadAge {
+			continue
+		}
+		os.RemoveAll(filepath.Join(tempDir, entry.Name()))
+	}
+}
@@ -11,11 +11,13 @@
 package main
 
 import (
-	"archive/zip"
+	"context"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
@@ -24,11 +26,14 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
-	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jchv/go-webview2"
+
+	"ltth-launcher/semver"
 )
 
 // Configuration constants
@@ -45,21 +50,59 @@ const (
 
 // LauncherConfig stores user preferences
 type LauncherConfig struct {
-	InstallPath      string   `json:"installPath"`
-	ConfigPath       string   `json:"configPath"`
-	AutoUpdate       bool     `json:"autoUpdate"`
-	Language         string   `json:"language"`
-	IsFirstRun       bool     `json:"isFirstRun"`
-	LastVersion      string   `json:"lastVersion"`
-	PreviousVersions []string `json:"previousVersions"`
+	InstallPath      string           `json:"installPath"`
+	ConfigPath       string           `json:"configPath"`
+	AutoUpdate       bool             `json:"autoUpdate"`
+	Language         string           `json:"language"`
+	IsFirstRun       bool             `json:"isFirstRun"`
+	LastVersion      string           `json:"lastVersion"`
+	PreviousVersions []string         `json:"previousVersions"`
+	RevokedKeys      []string         `json:"revokedKeys,omitempty"`
+	Channel          string           `json:"channel"`
+	SharedStore      bool             `json:"sharedStore"`
+	KeepVersions     int              `json:"keepVersions"`
+	KeepDays         int              `json:"keepDays"`
+	PinnedVersions   []string         `json:"pinnedVersions"`
+	AutoCleanup      bool             `json:"autoCleanup"`
+	ExtractLimits    ExtractLimits    `json:"extractLimits"`
+	Mirrors          MirrorList       `json:"mirrors"`
+	UpdateSource     string           `json:"updateSource"`
+	GitHubRepository string           `json:"githubRepository,omitempty"`
+	GitHubAPIURL     string           `json:"githubApiUrl,omitempty"`
+	ManifestMaxAge   int              `json:"manifestMaxAge"`
+	Network          NetworkSettings  `json:"network"`
+	Downloads        DownloadSettings `json:"downloads"`
 }
 
 // VersionInfo from remote version.json
 type VersionInfo struct {
-	Version     string                       `json:"version"`
-	ReleaseDate string                       `json:"releaseDate"`
-	Status      string                       `json:"status"`
-	Changelog   map[string]ChangelogEntry    `json:"changelog"`
+	Version     string                    `json:"version"`
+	ReleaseDate string                    `json:"releaseDate"`
+	Status      string                    `json:"status"`
+	Changelog   map[string]ChangelogEntry `json:"changelog"`
+	Channels    map[string]ChannelRelease `json:"channels,omitempty"`
+	Artifacts   map[string]ArtifactInfo   `json:"artifacts,omitempty"`
+	RevokedKeys []string                  `json:"revokedKeys,omitempty"`
+	Launcher    *LauncherRelease          `json:"launcher,omitempty"`
+	Mirrors     *MirrorList               `json:"mirrors,omitempty"`
+
+	// FetchedAt is when the data was last confirmed by the server, Offline
+	// whether it comes from the cache because the server wasn't reachable
+	FetchedAt time.Time `json:"-"`
+	Offline   bool      `json:"-"`
+}
+
+// ArtifactInfo describes the downloadable archive of a specific version
+type ArtifactInfo struct {
+	URL                string `json:"url,omitempty"`
+	MediaType          string `json:"mediaType,omitempty"`
+	SHA256             string `json:"sha256"`
+	Size               int64  `json:"size"`
+	MinLauncherVersion string `json:"minLauncherVersion,omitempty"`
+	Files              string `json:"files,omitempty"`
+	FilesSHA256        string `json:"filesSha256,omitempty"`
+	UnpackedSize       int64  `json:"unpackedSize,omitempty"`
+	MaxPathLength      int    `json:"maxPathLength,omitempty"`
 }
 
 // ChangelogEntry for a specific version
@@ -70,10 +113,16 @@ type ChangelogEntry struct {
 
 // Global variables
 var (
-	config     LauncherConfig
-	configPath string
-	logFile    *os.File
-	w          webview2.WebView
+	config      LauncherConfig
+	configPath  string
+	logFile     *os.File
+	w           webview2.WebView
+	versionInfo *VersionInfo
+	installing  atomic.Bool
+
+	// stateMu guards config and versionInfo, which the UI thread, install
+	// goroutines and the background scheduler share
+	stateMu sync.RWMutex
 )
 
 func main() {
@@ -83,9 +132,59 @@ func main() {
 
 	log.Println("Starting LTTH Launcher v" + AppVersion)
 
+	restoreLauncher := flag.Bool("restore-launcher", false, "switch back to the previous launcher binary")
+	cleanup := flag.Bool("cleanup", false, "remove installed versions according to the retention policy and exit")
+	dryRun := flag.Bool("dry-run", false, "with --cleanup: only print what would be removed")
+	prefetch := flag.Bool("prefetch", false, "stage the next update in the background if inside the download window and exit")
+	flag.Parse()
+
+	// Switch launcher binaries before anything else holds files open
+	if *restoreLauncher {
+		if err := restorePreviousLauncher(); err != nil {
+			log.Printf("Restoring previous launcher failed: %v", err)
+		} else {
+			return
+		}
+	} else if applyPendingLauncherUpdate() {
+		return
+	}
+
 	// Load or create configuration
 	loadConfig()
 
+	// Leftovers of a running launcher are still in use
+	firstInstance := claimInstance()
+	if *prefetch && !firstInstance {
+		log.Println("Another launcher is running, background update skipped")
+		return
+	}
+	if firstInstance {
+		sweepInstallLeftovers()
+		if err := collectStoreGarbage(); err != nil {
+			log.Printf("Shared store cleanup skipped: %v", err)
+		}
+	}
+
+	// Headless cleanup for scripts and scheduled tasks
+	if *cleanup {
+		if err := runHeadlessCleanup(*dryRun); err != nil {
+			log.Printf("Cleanup failed: %v", err)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Headless background update for the scheduled task
+	if *prefetch {
+		if err := runHeadlessPrefetch(); err != nil {
+			log.Printf("Background update failed: %v", err)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Create WebView window
 	w = webview2.NewWithOptions(webview2.WebViewOptions{
 		Debug:     false,
@@ -105,6 +204,8 @@ func main() {
 
 	// Bind Go functions to JavaScript
 	bindFunctions(w)
+	startProgressPump(w)
+	startBackgroundUpdates(w)
 
 	// Load UI using base64 encoding to avoid URL encoding issues
 	encodedHTML := base64.StdEncoding.EncodeToString([]byte(htmlUI))
@@ -157,6 +258,8 @@ func getConfigFilePath() string {
 
 // loadConfig loads or creates the configuration
 func loadConfig() {
+	stateMu.Lock()
+	defer stateMu.Unlock()
 	configPath = getConfigFilePath()
 	
 	// Ensure config directory exists
@@ -165,30 +268,50 @@ func loadConfig() {
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		// Create default config
-		config = LauncherConfig{
-			InstallPath:      "",
-			ConfigPath:       "",
-			AutoUpdate:       true,
-			Language:         "de",
-			IsFirstRun:       true,
-			LastVersion:      "",
-			PreviousVersions: []string{},
-		}
+		config = defaultConfig()
 		saveConfig()
 		return
 	}
 
+	// Settings missing in older config files keep their defaults
+	config = defaultConfig()
 	if err := json.Unmarshal(data, &config); err != nil {
 		log.Printf("Error parsing config: %v", err)
-		config = LauncherConfig{
-			AutoUpdate: true,
-			Language:   "de",
-			IsFirstRun: true,
-		}
+		config = defaultConfig()
+	}
+	if !isValidChannel(config.Channel) {
+		config.Channel = ChannelStable
+	}
+	if !isValidSource(config.UpdateSource) {
+		config.UpdateSource = SourceFeed
 	}
 }
 
-// saveConfig saves the configuration to disk
+// defaultConfig returns the configuration of a fresh installation
+func defaultConfig() LauncherConfig {
+	return LauncherConfig{
+		InstallPath:      "",
+		ConfigPath:       "",
+		AutoUpdate:       true,
+		Language:         "de",
+		IsFirstRun:       true,
+		LastVersion:      "",
+		PreviousVersions: []string{},
+		Channel:          ChannelStable,
+		SharedStore:      true,
+		KeepVersions:     3,
+		KeepDays:         30,
+		PinnedVersions:   []string{},
+		AutoCleanup:      false,
+		ExtractLimits:    defaultExtractLimits(),
+		UpdateSource:     SourceFeed,
+		ManifestMaxAge:   defaultManifestMaxAge,
+		Network:          defaultNetworkSettings(),
+		Downloads:        defaultDownloadSettings(),
+	}
+}
+
+// saveConfig saves the configuration to disk; the caller holds stateMu
 func saveConfig() error {
 	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
@@ -197,17 +320,71 @@ func saveConfig() error {
 	return os.WriteFile(configPath, data, 0644)
 }
 
+// currentConfig returns a copy of the configuration. Its slices are shared,
+// so they are only ever replaced, never changed in place.
+func currentConfig() LauncherConfig {
+	stateMu.RLock()
+	defer stateMu.RUnlock()
+	return config
+}
+
+// updateConfig applies change to the configuration while holding stateMu and saves it
+func updateConfig(change func(c *LauncherConfig)) error {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	change(&config)
+	return saveConfig()
+}
+
+// currentVersionInfo returns the last fetched version.json, or nil
+func currentVersionInfo() *VersionInfo {
+	stateMu.RLock()
+	defer stateMu.RUnlock()
+	return versionInfo
+}
+
+// setVersionInfo replaces the last fetched version.json
+func setVersionInfo(info *VersionInfo) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	versionInfo = info
+}
+
+// loadVersionInfo returns the last fetched version.json, fetching it if there is none
+func loadVersionInfo() (*VersionInfo, error) {
+	if info := currentVersionInfo(); info != nil {
+		return info, nil
+	}
+	info, err := fetchVersionInfo()
+	if err != nil {
+		return nil, err
+	}
+	setVersionInfo(info)
+	return info, nil
+}
+
+
 // bindFunctions binds Go functions to JavaScript
 func bindFunctions(w webview2.WebView) {
 	// Get configuration
 	w.Bind("getConfig", func() string {
+		cfg := currentConfig()
 		data, _ := json.Marshal(map[string]interface{}{
-			"installPath":  config.InstallPath,
-			"configPath":   config.ConfigPath,
-			"autoUpdate":   config.AutoUpdate,
-			"language":     config.Language,
-			"isFirstRun":   config.IsFirstRun,
-			"lastVersion":  config.LastVersion,
+			"installPath":     cfg.InstallPath,
+			"configPath":      cfg.ConfigPath,
+			"autoUpdate":      cfg.AutoUpdate,
+			"language":        cfg.Language,
+			"isFirstRun":      cfg.IsFirstRun,
+			"lastVersion":     cfg.LastVersion,
+			"channel":         cfg.Channel,
+			"launcherVersion": AppVersion,
+			"keepVersions":    cfg.KeepVersions,
+			"keepDays":        cfg.KeepDays,
+			"pinnedVersions":  cfg.PinnedVersions,
+			"autoCleanup":     cfg.AutoCleanup,
+			"updateSource":    cfg.UpdateSource,
+			"proxy":           cfg.Network.Proxy,
+			"downloads":       cfg.Downloads,
 		})
 		return string(data)
 	})
@@ -219,27 +396,90 @@ func bindFunctions(w webview2.WebView) {
 			return `{"success": false, "error": "Invalid JSON"}`
 		}
 
-		if v, ok := updates["installPath"].(string); ok {
-			config.InstallPath = v
-		}
-		if v, ok := updates["configPath"].(string); ok {
-			config.ConfigPath = v
-		}
-		if v, ok := updates["autoUpdate"].(bool); ok {
-			config.AutoUpdate = v
+		// Reject invalid values before anything changes
+		proxy, setProxy := updates["proxy"].(string)
+		proxy = strings.TrimSpace(proxy)
+		if setProxy && proxy != "" && proxy != proxyDirect {
+			if _, err := parseProxyURL(proxy); err != nil {
+				return errorResponse("proxy", err)
+			}
 		}
-		if v, ok := updates["language"].(string); ok {
-			config.Language = v
+		for _, key := range []string{"windowStart", "windowEnd"} {
+			if v, ok := updates[key].(string); ok && v != "" {
+				if _, err := parseClock(v); err != nil {
+					return errorResponse("downloadWindow", err)
+				}
+			}
 		}
-		if v, ok := updates["isFirstRun"].(bool); ok {
-			config.IsFirstRun = v
+
+		scheduleChanged := false
+		err := updateConfig(func(c *LauncherConfig) {
+			if v, ok := updates["installPath"].(string); ok {
+				c.InstallPath = v
+			}
+			if v, ok := updates["configPath"].(string); ok {
+				c.ConfigPath = v
+			}
+			if v, ok := updates["autoUpdate"].(bool); ok {
+				c.AutoUpdate = v
+			}
+			if v, ok := updates["language"].(string); ok {
+				c.Language = v
+			}
+			if v, ok := updates["isFirstRun"].(bool); ok {
+				c.IsFirstRun = v
+			}
+			if v, ok := updates["lastVersion"].(string); ok {
+				c.LastVersion = v
+			}
+			if v, ok := updates["channel"].(string); ok && isValidChannel(v) {
+				c.Channel = v
+			}
+			if v, ok := updates["updateSource"].(string); ok && isValidSource(v) && v != c.UpdateSource {
+				// Metadata of the previous source doesn't apply to the new one
+				c.UpdateSource = v
+				versionInfo = nil
+			}
+			if v, ok := updates["keepVersions"].(float64); ok && v >= 0 {
+				c.KeepVersions = int(v)
+			}
+			if v, ok := updates["keepDays"].(float64); ok && v >= 0 {
+				c.KeepDays = int(v)
+			}
+			if v, ok := updates["autoCleanup"].(bool); ok {
+				c.AutoCleanup = v
+			}
+			if setProxy {
+				c.Network.Proxy = proxy
+			}
+			if v, ok := updates["rateLimit"].(float64); ok && v >= 0 {
+				c.Downloads.RateLimit = int(v)
+			}
+			if v, ok := updates["backgroundRateLimit"].(float64); ok && v >= 0 {
+				c.Downloads.BackgroundRateLimit = int(v)
+			}
+			for key, field := range map[string]*string{"windowStart": &c.Downloads.WindowStart, "windowEnd": &c.Downloads.WindowEnd} {
+				if v, ok := updates[key].(string); ok {
+					scheduleChanged = scheduleChanged || *field != v
+					*field = v
+				}
+			}
+			if v, ok := updates["backgroundUpdates"].(bool); ok {
+				scheduleChanged = scheduleChanged || c.Downloads.Background != v
+				c.Downloads.Background = v
+			}
+		})
+
+		if setProxy {
+			resetTransport()
 		}
-		if v, ok := updates["lastVersion"].(string); ok {
-			config.LastVersion = v
+		if scheduleChanged {
+			if err := updatePrefetchTask(); err != nil {
+				log.Printf("Scheduling background updates failed: %v", err)
+			}
 		}
-
-		if err := saveConfig(); err != nil {
-			return fmt.Sprintf(`{"success": false, "error": "%s"}`, err.Error())
+		if err != nil {
+			return failureResponse(err.Error())
 		}
 		return `{"success": true}`
 	})
@@ -281,133 +521,303 @@ func bindFunctions(w webview2.WebView) {
 		return strings.TrimSpace(string(output))
 	})
 
+	// Request the update source with the current network settings
+	w.Bind("testConnection", func() string {
+		data, _ := json.Marshal(testConnection())
+		return string(data)
+	})
+
 	// Check for updates
 	w.Bind("checkUpdates", func() string {
 		log.Println("Checking for updates...")
 		
-		resp, err := http.Get(VersionURL)
+		info, err := fetchVersionInfo()
 		if err != nil {
 			log.Printf("Update check failed: %v", err)
-			return fmt.Sprintf(`{"success": false, "error": "%s"}`, err.Error())
+			return failureResponse(err.Error())
 		}
-		defer resp.Body.Close()
+		setVersionInfo(info)
 
-		var versionInfo VersionInfo
-		if err := json.NewDecoder(resp.Body).Decode(&versionInfo); err != nil {
-			return fmt.Sprintf(`{"success": false, "error": "Invalid version data"}`)
+		cfg := currentConfig()
+		release, channel, ok := selectRelease(info, cfg.Channel)
+		if !ok {
+			return failureResponse("No release available for channel " + cfg.Channel)
 		}
 
-		currentVersion := config.LastVersion
-		latestVersion := versionInfo.Version
+		currentVersion := cfg.LastVersion
+		latestVersion := release.Version
 		updateAvailable := currentVersion != "" && compareVersions(latestVersion, currentVersion) > 0
 		
 		if currentVersion == "" {
 			updateAvailable = true
 		}
 
+		// After switching to a more stable channel the installed version can be
+		// newer than anything offered; offer the channel release as a downgrade
+		downgrade := currentVersion != "" && compareVersions(latestVersion, currentVersion) < 0
+		if downgrade {
+			updateAvailable = true
+		}
+
 		// Get changelog
 		var changelog []string
-		if entry, ok := versionInfo.Changelog[latestVersion]; ok {
+		if entry, ok := info.Changelog[latestVersion]; ok {
 			changelog = entry.Changes
 		}
 
 		result := map[string]interface{}{
-			"success":         true,
-			"currentVersion":  currentVersion,
-			"latestVersion":   latestVersion,
-			"updateAvailable": updateAvailable,
-			"changelog":       changelog,
-			"releaseDate":     versionInfo.ReleaseDate,
-			"status":          versionInfo.Status,
+			"success":          true,
+			"currentVersion":   currentVersion,
+			"latestVersion":    latestVersion,
+			"updateAvailable":  updateAvailable,
+			"downgrade":        downgrade,
+			"changelog":        changelog,
+			"releaseDate":      release.ReleaseDate,
+			"status":           channel,
+			"channel":          cfg.Channel,
+			"launcherRequired": !launcherCompatible(info.Artifacts[latestVersion].MinLauncherVersion),
+			"offline":          info.Offline,
+			"fetchedAt":        info.FetchedAt.UnixMilli(),
+			"staged":           updateAvailable && !downgrade && isVersionInstalled(latestVersion),
 		}
 
 		data, _ := json.Marshal(result)
-		log.Printf("Update check result: current=%s, latest=%s, available=%v", currentVersion, latestVersion, updateAvailable)
+		log.Printf("Update check result: channel=%s, current=%s, latest=%s (%s), available=%v, downgrade=%v", cfg.Channel, currentVersion, latestVersion, channel, updateAvailable, downgrade)
 		return string(data)
 	})
 
-	// Install update
+	// Install update; runs in the background and reports via onLauncherProgress / onInstallResult
 	w.Bind("installUpdate", func(version string) string {
-		log.Printf("Installing version %s...", version)
-		
-		if config.InstallPath == "" || config.ConfigPath == "" {
-			return `{"success": false, "error": "Paths not configured"}`
+		return startInstallTask(w, func() string {
+			return performInstall(version, true, uiProgress)
+		})
+	})
+
+	// Install any published version side by side without activating it
+	w.Bind("installVersion", func(version string) string {
+		return startInstallTask(w, func() string {
+			return performInstall(version, false, uiProgress)
+		})
+	})
+
+	// Select a local release archive for an offline install
+	w.Bind("selectArchiveFile", func(title string) string {
+		script := fmt.Sprintf(`
+			Add-Type -AssemblyName System.Windows.Forms
+			$dialog = New-Object System.Windows.Forms.OpenFileDialog
+			$dialog.Title = '%s'
+			$dialog.Filter = 'LTTH (*.zip;*.tar.gz;*.tgz;*.tar.zst)|*.zip;*.tar.gz;*.tgz;*.tar.zst'
+			if ($dialog.ShowDialog() -eq 'OK') {
+				$dialog.FileName
+			}
+		`, strings.ReplaceAll(title, "'", "''"))
+
+		cmd := exec.Command("powershell", "-NoProfile", "-Command", script)
+		output, err := cmd.Output()
+		if err != nil {
+			return ""
 		}
+		return strings.TrimSpace(string(output))
+	})
 
-		// Ensure directories exist
-		os.MkdirAll(config.InstallPath, 0755)
-		os.MkdirAll(config.ConfigPath, 0755)
+	// Install a version from a local archive; runs in the background like installUpdate
+	w.Bind("installFromFile", func(path string, activate bool) string {
+		return startInstallTask(w, func() string {
+			return performFileInstall(path, activate, uiProgress)
+		})
+	})
 
-		// Backup existing config
-		if err := backupConfig(); err != nil {
-			log.Printf("Config backup warning: %v", err)
+	// Preview which versions the retention policy would remove; reports via onInstallResult
+	w.Bind("getCleanupPlan", func() string {
+		return startInstallTask(w, func() string {
+			data, _ := json.Marshal(map[string]interface{}{"success": true, "plan": planCleanup()})
+			return string(data)
+		})
+	})
+
+	// Remove the versions the retention policy doesn't keep; reports via onInstallResult
+	w.Bind("runCleanup", func() string {
+		return startInstallTask(w, func() string {
+			plan := planCleanup()
+			removed, err := applyCleanup(plan)
+			if err != nil {
+				return failureResponse(err.Error())
+			}
+			data, _ := json.Marshal(map[string]interface{}{"success": true, "removed": removed, "reclaimed": plan.Reclaimed})
+			return string(data)
+		})
+	})
+
+	// Pin or unpin a version so cleanup never removes it
+	w.Bind("setVersionPinned", func(version string, pinned bool) string {
+		if !isValidVersionName(version) {
+			return errorResponse("invalidVersion", fmt.Errorf("invalid version: %q", version))
+		}
+		err := updateConfig(func(c *LauncherConfig) {
+			kept := []string{}
+			for _, v := range c.PinnedVersions {
+				if v != version {
+					kept = append(kept, v)
+				}
+			}
+			if pinned {
+				kept = append(kept, version)
+			}
+			c.PinnedVersions = kept
+		})
+		if err != nil {
+			return failureResponse(err.Error())
 		}
+		return `{"success": true}`
+	})
 
-		// Download ZIP - always use ltth_latest.zip from the repo
-		zipURL := AppZIPBaseURL + "ltth_latest.zip"
-		tempDir := filepath.Join(config.InstallPath, ".temp")
-		os.MkdirAll(tempDir, 0755)
-		zipPath := filepath.Join(tempDir, "ltth_latest.zip")
+	// Check an installed version against its file manifest; reports via onInstallResult
+	w.Bind("verifyInstallation", func(version string) string {
+		if !isValidVersionName(version) || !isVersionInstalled(version) {
+			return errorResponse("notInstalled", fmt.Errorf("version %s is not installed", version))
+		}
+		return startInstallTask(w, func() string {
+			report, err := verifyInstallation(version, uiProgress)
+			if err != nil {
+				return failureResponse(err.Error())
+			}
+			return verifyResponse(report, 0)
+		})
+	})
 
-		log.Printf("Downloading from: %s", zipURL)
-		if err := downloadFile(zipPath, zipURL); err != nil {
-			return fmt.Sprintf(`{"success": false, "error": "Download failed: %s"}`, err.Error())
+	// Restore modified and missing files of an installed version
+	w.Bind("repairInstallation", func(version string) string {
+		return startInstallTask(w, func() string {
+			return performRepair(version, uiProgress)
+		})
+	})
+
+	// Report whether a newer launcher is published or already staged
+	w.Bind("checkLauncherUpdate", func() string {
+		info, err := loadVersionInfo()
+		if err != nil {
+			return failureResponse(err.Error())
+		}
+
+		result := map[string]interface{}{
+			"success":         true,
+			"currentVersion":  AppVersion,
+			"updateAvailable": launcherUpdateAvailable(info),
 		}
+		if info.Launcher != nil {
+			result["latestVersion"] = info.Launcher.Version
+		}
+		if exe, err := launcherExecutable(); err == nil {
+			if staged, ok := loadStagedLauncher(exe); ok {
+				result["stagedVersion"] = staged.Version
+			}
+		}
+		data, _ := json.Marshal(result)
+		return string(data)
+	})
+
+	// Download and stage the new launcher; reports via onLauncherProgress / onInstallResult
+	w.Bind("installLauncherUpdate", func() string {
+		return startInstallTask(w, func() string {
+			return performLauncherUpdate(uiProgress)
+		})
+	})
 
-		// Extract ZIP to version-specific directory
-		versionDir := filepath.Join(config.InstallPath, version)
-		log.Printf("Extracting to: %s", versionDir)
-		if err := extractZip(zipPath, versionDir); err != nil {
-			return fmt.Sprintf(`{"success": false, "error": "Extraction failed: %s"}`, err.Error())
+	// Restart the launcher so a staged update is applied
+	w.Bind("restartLauncher", func() string {
+		if installing.Load() {
+			return `{"success": false, "error": "Installation running"}`
+		}
+		exe, err := launcherExecutable()
+		if err != nil {
+			return failureResponse(err.Error())
 		}
+		if err := startLauncher(exe); err != nil {
+			return failureResponse(err.Error())
+		}
+		w.Terminate()
+		return `{"success": true}`
+	})
 
-		// Update config
-		if config.LastVersion != "" && config.LastVersion != version {
-			config.PreviousVersions = append(config.PreviousVersions, config.LastVersion)
-			if len(config.PreviousVersions) > 5 {
-				config.PreviousVersions = config.PreviousVersions[len(config.PreviousVersions)-5:]
+	// Get all published versions with install state; offline only the installed ones
+	w.Bind("getVersionCatalogue", func() string {
+		info := currentVersionInfo()
+		if info == nil || info.Offline {
+			fetched, err := fetchVersionInfo()
+			if err != nil {
+				log.Printf("Version catalogue offline: %v", err)
+				data, _ := json.Marshal(map[string]interface{}{
+					"success":  true,
+					"offline":  true,
+					"versions": buildCatalogue(&VersionInfo{}),
+				})
+				return string(data)
 			}
+			info = fetched
+			setVersionInfo(info)
 		}
-		config.LastVersion = version
-		saveConfig()
 
-		// Cleanup
-		os.RemoveAll(tempDir)
+		data, _ := json.Marshal(map[string]interface{}{
+			"success":   true,
+			"offline":   info.Offline,
+			"fetchedAt": info.FetchedAt.UnixMilli(),
+			"versions":  buildCatalogue(info),
+		})
+		return string(data)
+	})
+
+	// Mark an installed version as the one started by "Start"
+	w.Bind("setActiveVersion", func(version string) string {
+		if !isValidVersionName(version) || !isVersionInstalled(version) {
+			return errorResponse("notInstalled", fmt.Errorf("version %s is not installed", version))
+		}
+		activateVersion(version)
 
-		log.Printf("Version %s installed successfully", version)
-		return fmt.Sprintf(`{"success": true, "version": "%s"}`, version)
+		log.Printf("Activated version %s", version)
+		return versionResponse(version)
 	})
 
 	// Rollback to previous version
 	w.Bind("rollback", func() string {
-		if len(config.PreviousVersions) == 0 {
+		previous := currentConfig().PreviousVersions
+		if len(previous) == 0 {
 			return `{"success": false, "error": "No previous version available"}`
 		}
 
-		prevVersion := config.PreviousVersions[len(config.PreviousVersions)-1]
-		config.PreviousVersions = config.PreviousVersions[:len(config.PreviousVersions)-1]
-		config.LastVersion = prevVersion
-		saveConfig()
+		prevVersion := previous[len(previous)-1]
+		if !isVersionInstalled(prevVersion) {
+			return errorResponse("notInstalled", fmt.Errorf("version %s is not installed", prevVersion))
+		}
+		updateConfig(func(c *LauncherConfig) {
+			if n := len(c.PreviousVersions); n > 0 && c.PreviousVersions[n-1] == prevVersion {
+				c.PreviousVersions = c.PreviousVersions[:n-1]
+			}
+			c.LastVersion = prevVersion
+		})
 
 		log.Printf("Rolled back to version %s", prevVersion)
-		return fmt.Sprintf(`{"success": true, "version": "%s"}`, prevVersion)
+		return versionResponse(prevVersion)
 	})
 
 	// Launch application
 	w.Bind("launchApp", func() string {
-		if config.InstallPath == "" || config.LastVersion == "" {
+		cfg := currentConfig()
+		if cfg.InstallPath == "" || cfg.LastVersion == "" {
 			return `{"success": false, "error": "No version installed"}`
 		}
 
-		appDir := filepath.Join(config.InstallPath, config.LastVersion)
+		appDir := filepath.Join(cfg.InstallPath, cfg.LastVersion)
 		
 		// Validate that appDir is within installPath (prevent path traversal)
 		cleanAppDir := filepath.Clean(appDir)
-		cleanInstallPath := filepath.Clean(config.InstallPath)
+		cleanInstallPath := filepath.Clean(cfg.InstallPath)
 		relPath, err := filepath.Rel(cleanInstallPath, cleanAppDir)
 		if err != nil || strings.HasPrefix(relPath, "..") {
 			return `{"success": false, "error": "Invalid installation path"}`
 		}
+		if !isInstallComplete(cleanAppDir) {
+			return `{"success": false, "error": "Installation is incomplete, please reinstall this version"}`
+		}
 		
 		// Look for index.html to open in browser
 		indexPath := filepath.Join(cleanAppDir, "index.html")
@@ -420,7 +830,7 @@ func bindFunctions(w webview2.WebView) {
 			fileURL := "file:///" + strings.ReplaceAll(indexPath, "\\", "/")
 			cmd := exec.Command("cmd", "/c", "start", "", fileURL)
 			if err := cmd.Start(); err != nil {
-				return fmt.Sprintf(`{"success": false, "error": "%s"}`, err.Error())
+				return failureResponse(err.Error())
 			}
 			log.Println("Opened app in browser")
 			return `{"success": true}`
@@ -432,7 +842,7 @@ func bindFunctions(w webview2.WebView) {
 			cmd := exec.Command("node", launchJS)
 			cmd.Dir = cleanAppDir
 			if err := cmd.Start(); err != nil {
-				return fmt.Sprintf(`{"success": false, "error": "%s"}`, err.Error())
+				return failureResponse(err.Error())
 			}
 			log.Println("Started Node.js app")
 			return `{"success": true}`
@@ -460,178 +870,439 @@ func bindFunctions(w webview2.WebView) {
 
 	// Get installed versions
 	w.Bind("getInstalledVersions", func() string {
-		if config.InstallPath == "" {
-			return "[]"
-		}
-
-		entries, err := os.ReadDir(config.InstallPath)
-		if err != nil {
+		versions := installedVersions()
+		if versions == nil {
 			return "[]"
 		}
 
-		var versions []string
-		for _, entry := range entries {
-			if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
-				versions = append(versions, entry.Name())
-			}
-		}
-
-		// Sort descending
-		sort.Sort(sort.Reverse(sort.StringSlice(versions)))
-
 		data, _ := json.Marshal(versions)
 		return string(data)
 	})
 }
 
-// compareVersions compares two version strings
-func compareVersions(v1, v2 string) int {
-	// Simple version comparison (works for X.Y.Z format)
-	v1Parts := strings.Split(strings.TrimPrefix(v1, "v"), ".")
-	v2Parts := strings.Split(strings.TrimPrefix(v2, "v"), ".")
-
-	for i := 0; i < 3; i++ {
-		var p1, p2 int
-		if i < len(v1Parts) {
-			// Parse version part, ignore errors (default to 0)
-			if _, err := fmt.Sscanf(v1Parts[i], "%d", &p1); err != nil {
-				p1 = 0
-			}
+// performInstall downloads, verifies and extracts a version, reporting progress to report.
+// With activate set the version becomes the one launched by "Start".
+func performInstall(version string, activate bool, report ProgressFunc) string {
+	log.Printf("Installing version %s...", version)
+	
+	cfg := currentConfig()
+	if cfg.InstallPath == "" || cfg.ConfigPath == "" {
+		return `{"success": false, "error": "Paths not configured"}`
+	}
+
+	if !isValidVersionName(version) {
+		return errorResponse("invalidVersion", fmt.Errorf("invalid version: %q", version))
+	}
+
+	// A version staged in the background only has to be activated, after the
+	// same config backup a full install makes
+	if activate && isVersionInstalled(version) {
+		if err := backupConfig(report); err != nil {
+			log.Printf("Config backup before activating %s failed: %v", version, err)
+			return errorResponse("backup", err)
 		}
-		if i < len(v2Parts) {
-			// Parse version part, ignore errors (default to 0)
-			if _, err := fmt.Sscanf(v2Parts[i], "%d", &p2); err != nil {
-				p2 = 0
-			}
+		if err := activateVersion(version); err != nil {
+			return failureResponse(err.Error())
+		}
+		cleanupAfterInstall(true)
+		log.Printf("Staged version %s applied", version)
+		return versionResponse(version)
+	}
+
+	// The whole install works with the version.json fetched here
+	info, err := loadVersionInfo()
+	if err != nil {
+		return failureResponse(err.Error())
+	}
+
+	// Refuse versions that need a newer launcher
+	if minVersion := info.Artifacts[version].MinLauncherVersion; !launcherCompatible(minVersion) {
+		return errorResponse("launcherTooOld", fmt.Errorf("version %s requires launcher %s (this is %s)", version, minVersion, AppVersion))
+	}
+
+	// Check permissions, free space and path length before downloading anything
+	if issues := preflightInstall(info, version); len(issues) > 0 {
+		log.Printf("Preflight for %s failed: %+v", version, issues)
+		return preflightResponse(issues)
+	}
+
+	// Prefer a file-level delta against the active version
+	tempDir := filepath.Join(cfg.InstallPath, tempDirName)
+	staging, err := stageDelta(info, version, report)
+	if errors.Is(err, errDownloadInterrupted) {
+		return failureResponse(err.Error())
+	}
+	if err != nil {
+		if err != errNoDelta {
+			log.Printf("Delta update to %s failed, using full archive: %v", version, err)
 		}
-		if p1 > p2 {
-			return 1
+
+		// Download and verify the archive of exactly the requested version
+		zipPath, failure := downloadArchive(info, version, report)
+		if failure != "" {
+			return failure
+		}
+
+		// Extract into staging
+		log.Printf("Extracting %s", zipPath)
+		staging, err = stageArchive(zipPath, version, archiveMediaType(info, version), report)
+		if errors.Is(err, errUnsafeArchive) {
+			log.Printf("Archive of %s rejected: %v", version, err)
+			return errorResponse("unsafeArchive", err)
 		}
-		if p1 < p2 {
-			return -1
+		if err != nil {
+			log.Printf("Install of %s failed: %v", version, err)
+			return failureResponse(err.Error())
 		}
 	}
-	return 0
+
+	if err := commitInstall(staging, version, activate, report); err != nil {
+		log.Printf("Install of %s failed: %v", version, err)
+		return failureResponse(err.Error())
+	}
+	os.RemoveAll(tempDir)
+	cleanupAfterInstall(activate)
+
+	log.Printf("Version %s installed successfully", version)
+	return versionResponse(version)
 }
 
-// downloadFile downloads a file from URL
-func downloadFile(filepath string, url string) error {
-	resp, err := http.Get(url)
-	if err != nil {
+// commitInstall backs up the configuration, moves a staged version into place
+// and activates it if requested or if no version is active yet
+func commitInstall(staging, version string, activate bool, report ProgressFunc) error {
+	// Backup existing config
+	if err := backupConfig(report); err != nil {
+		log.Printf("Config backup warning: %v", err)
+	}
+
+	// Move the complete version into place
+	versionDir := filepath.Join(currentConfig().InstallPath, version)
+	if err := commitStaging(staging, versionDir); err != nil {
+		os.RemoveAll(staging)
 		return err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("bad status: %s", resp.Status)
+	// Update config
+	if activate || currentConfig().LastVersion == "" {
+		activateVersion(version)
 	}
+	return nil
+}
 
-	out, err := os.Create(filepath)
-	if err != nil {
-		return err
+// cleanupAfterInstall applies the retention policy after an activating
+// install, otherwise it only drops unused blobs from the shared store
+func cleanupAfterInstall(activate bool) {
+	if activate && currentConfig().AutoCleanup {
+		if _, err := applyCleanup(planCleanup()); err != nil {
+			log.Printf("Automatic cleanup failed: %v", err)
+		}
+	} else if err := collectStoreGarbage(); err != nil {
+		log.Printf("Shared store cleanup skipped: %v", err)
 	}
-	defer out.Close()
+}
 
-	_, err = io.Copy(out, resp.Body)
-	return err
+// downloadArchive downloads the archive of version into the temp folder and
+// verifies checksum and signature, failing over to the next mirror on any
+// error; on failure it returns the IPC error result of the last mirror
+func downloadArchive(info *VersionInfo, version string, report ProgressFunc) (string, string) {
+	// Don't download what couldn't be verified anyway
+	if info.Artifacts[version].SHA256 == "" {
+		log.Printf("Refusing to install %s: no checksum published", version)
+		return "", errorResponse("checksum", fmt.Errorf("version %s: %w", version, errNoChecksum))
+	}
+	tempDir := filepath.Join(currentConfig().InstallPath, tempDirName)
+	os.MkdirAll(tempDir, 0755)
+	zipPath := filepath.Join(tempDir, "ltth_"+version+archiveExtensions[archiveMediaType(info, version)])
+
+	sources := artifactSources(artifactRef(info, version))
+	var failure string
+	for i, source := range sources {
+		var err error
+		if failure, err = downloadArchiveFrom(info, source.URL, zipPath, version, i == len(sources)-1, report); err == nil {
+			recordMirrorSuccess(source.Mirror)
+			return zipPath, ""
+		}
+		if errors.Is(err, errDownloadInterrupted) {
+			// Not the mirror's fault; the partial file is resumed next time
+			break
+		}
+		recordMirrorFailure(source.Mirror, err)
+	}
+	return "", failure
 }
 
-// extractZip extracts a ZIP file
-func extractZip(src, dest string) error {
-	r, err := zip.OpenReader(src)
+// downloadArchiveFrom downloads and verifies the archive of version from one
+// URL; on failure it returns the IPC error result and its cause
+func downloadArchiveFrom(info *VersionInfo, zipURL, zipPath, version string, last bool, report ProgressFunc) (string, error) {
+	log.Printf("Downloading from: %s", zipURL)
+	sum, err := downloadFromMirror(zipPath, zipURL, last, report)
 	if err != nil {
-		return err
+		return failureResponse("Download failed: " + err.Error()), err
+	}
+
+	// Verify the archive against the checksum published in version.json
+	if report != nil {
+		report(ProgressEvent{Phase: PhaseVerify, Unit: UnitBytes, Total: -1})
+	}
+	if err := verifyArtifact(info, zipPath, sum, version); err != nil {
+		log.Printf("Archive verification failed: %v", err)
+		os.Remove(zipPath)
+		return errorResponse("checksum", err), err
 	}
-	defer r.Close()
 
-	// Clean and normalize destination path
-	cleanDest := filepath.Clean(dest)
-	os.MkdirAll(cleanDest, 0755)
+	// Verify the release signature before anything is extracted
+	if err := verifyArtifactSignature(zipPath, zipURL); err != nil {
+		log.Printf("Archive signature rejected: %v", err)
+		os.Remove(zipPath)
+		return errorResponse("signature", err), err
+	}
+	return "", nil
+}
 
-	for _, f := range r.File {
-		// Clean the file name to prevent directory traversal
-		cleanName := filepath.Clean(f.Name)
-		if strings.HasPrefix(cleanName, "..") {
-			return fmt.Errorf("invalid file path in archive: %s", f.Name)
+// maxPreviousVersions is how many versions are offered for rollback
+const maxPreviousVersions = 5
+
+// activateVersion makes version the active one, remembers the previous version
+// for rollback and saves the configuration
+func activateVersion(version string) error {
+	return updateConfig(func(c *LauncherConfig) {
+		if c.LastVersion != "" && c.LastVersion != version {
+			c.PreviousVersions = append(c.PreviousVersions, c.LastVersion)
+			if len(c.PreviousVersions) > maxPreviousVersions {
+				c.PreviousVersions = c.PreviousVersions[len(c.PreviousVersions)-maxPreviousVersions:]
+			}
 		}
-		
-		fpath := filepath.Join(cleanDest, cleanName)
+		c.LastVersion = version
+	})
+}
 
-		// Security check for zip slip vulnerability using filepath.Rel
-		relPath, err := filepath.Rel(cleanDest, fpath)
-		if err != nil || strings.HasPrefix(relPath, "..") {
-			return fmt.Errorf("invalid file path: %s", fpath)
+// isVersionInstalled reports whether a complete version folder exists in the install path
+func isVersionInstalled(version string) bool {
+	installPath := currentConfig().InstallPath
+	if installPath == "" {
+		return false
+	}
+	dir := filepath.Join(installPath, version)
+	info, err := os.Stat(dir)
+	return err == nil && info.IsDir() && isInstallComplete(dir)
+}
+
+// CatalogueEntry is one published version as shown in the version catalogue
+type CatalogueEntry struct {
+	Version     string   `json:"version"`
+	Date        string   `json:"date"`
+	Changes     []string `json:"changes"`
+	Size        int64    `json:"size,omitempty"`
+	Installed   bool     `json:"installed"`
+	Active      bool     `json:"active"`
+	Latest      bool     `json:"latest"`
+	Compatible  bool     `json:"compatible"`
+	MinLauncher string   `json:"minLauncherVersion,omitempty"`
+	Pinned      bool     `json:"pinned"`
+}
+
+// buildCatalogue lists every version known from changelog and artifacts plus
+// installed versions (e.g. from offline installs), newest first
+func buildCatalogue(info *VersionInfo) []CatalogueEntry {
+	seen := make(map[string]bool)
+	var versions []string
+	for v := range info.Changelog {
+		seen[v] = true
+		versions = append(versions, v)
+	}
+	for v := range info.Artifacts {
+		if !seen[v] {
+			seen[v] = true
+			versions = append(versions, v)
+		}
+	}
+	for _, v := range installedVersions() {
+		if !seen[v] {
+			seen[v] = true
+			versions = append(versions, v)
 		}
+	}
+	semver.SortStrings(versions)
 
-		if f.FileInfo().IsDir() {
-			os.MkdirAll(fpath, 0755)
+	active := currentConfig().LastVersion
+	entries := make([]CatalogueEntry, 0, len(versions))
+	for _, v := range versions {
+		if !isValidVersionName(v) {
 			continue
 		}
-
-		if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
-			return err
+		artifact := info.Artifacts[v]
+		entry := CatalogueEntry{
+			Version:     v,
+			Date:        info.Changelog[v].Date,
+			Changes:     info.Changelog[v].Changes,
+			Size:        artifact.Size,
+			Installed:   isVersionInstalled(v),
+			Active:      v == active,
+			Latest:      v == info.Version,
+			Compatible:  launcherCompatible(artifact.MinLauncherVersion),
+			MinLauncher: artifact.MinLauncherVersion,
+			Pinned:      isPinned(v),
+		}
+		if entry.Date == "" && entry.Latest {
+			entry.Date = info.ReleaseDate
 		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
 
-		outFile, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
+// compareVersions compares two version strings by SemVer 2.0 precedence
+func compareVersions(v1, v2 string) int {
+	return semver.CompareStrings(v1, v2)
+}
+
+// launcherCompatible reports whether this launcher satisfies a minLauncherVersion
+// entry, which is either a plain version ("1.0.1" = at least 1.0.1) or a range (">=1.0.1 <2")
+func launcherCompatible(requirement string) bool {
+	if requirement == "" {
+		return true
+	}
+	current, err := semver.Parse(AppVersion)
+	if err != nil {
+		return false
+	}
+	if min, err := semver.ParseLoose(requirement); err == nil {
+		return current.Compare(min) >= 0
+	}
+	constraint, err := semver.ParseConstraint(requirement)
+	if err != nil {
+		log.Printf("Invalid launcher requirement %q: %v", requirement, err)
+		return false
+	}
+	return constraint.Check(current)
+}
+
+// fetchVersionInfo fetches the release metadata from the configured update
+// source; if it can't be reached, the last cached metadata is returned marked
+// as offline
+func fetchVersionInfo() (*VersionInfo, error) {
+	source := currentUpdateSource()
+	info, err := source.Fetch()
+	if err == nil {
+		return info, nil
+	}
+	cached := source.Cached()
+	if cached == nil {
+		return nil, err
+	}
+	log.Printf("Update source %s unavailable (%v), using cached data from %s", source.Name(), err, cached.FetchedAt.Format(time.RFC3339))
+	cached.Offline = true
+	return cached, nil
+}
+
+// fetchVersionInfoFrom loads version.json from url. A fresh cached copy is
+// used as is, an older one is revalidated with a conditional request.
+func fetchVersionInfoFrom(url string) (*VersionInfo, error) {
+	cached := loadCachedResponse(url)
+	if cached != nil {
+		info, err := versionInfoFromCache(cached)
 		if err != nil {
-			return err
+			log.Printf("Discarding cached %s: %v", url, err)
+			cached = nil
+		} else if cached.fresh(time.Now()) {
+			return info, nil
 		}
+	}
 
-		rc, err := f.Open()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, modified, err := fetchConditional(httpClient(), req, cached)
+	if err != nil {
+		return nil, err
+	}
+	if modified {
+		sig, err := fetchManifestSignature(response.Data, url)
 		if err != nil {
-			outFile.Close()
-			return err
+			return nil, err
 		}
+		response.Signature = sig
+	}
 
-		_, err = io.Copy(outFile, rc)
-		outFile.Close()
-		rc.Close()
+	info, err := versionInfoFromCache(response)
+	if err != nil {
+		return nil, err
+	}
+	storeCachedResponse(response)
+	return info, nil
+}
 
-		if err != nil {
-			return err
-		}
+// parseVersionInfo parses verified version.json data
+func parseVersionInfo(data []byte) (*VersionInfo, error) {
+	var info VersionInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("Invalid version data")
 	}
+	return &info, nil
+}
 
-	return nil
+// downloadFile downloads a file from URL, resuming and retrying on connection
+// loss, and returns its SHA256
+func downloadFile(filepath string, url string, report ProgressFunc) (string, error) {
+	d := newDownloader()
+	d.Progress = report
+	return d.Download(context.Background(), filepath, url)
 }
 
 // backupConfig backs up user configuration
-func backupConfig() error {
-	if config.ConfigPath == "" {
+func backupConfig(report ProgressFunc) error {
+	configDir := currentConfig().ConfigPath
+	if configDir == "" {
 		return nil
 	}
 
-	if _, err := os.Stat(config.ConfigPath); os.IsNotExist(err) {
+	if _, err := os.Stat(configDir); os.IsNotExist(err) {
 		return nil
 	}
 
-	backupDir := filepath.Join(config.ConfigPath, ".backup", time.Now().Format("20060102-150405"))
+	backupDir := filepath.Join(configDir, ".backup", time.Now().Format("20060102-150405"))
 	os.MkdirAll(backupDir, 0755)
 
-	entries, err := os.ReadDir(config.ConfigPath)
+	files, err := configBackupFiles(configDir)
 	if err != nil {
 		return err
 	}
+	progress := newProgressTracker(PhaseBackup, UnitFiles, int64(len(files)), report)
 
-	for _, entry := range entries {
-		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
-			continue
-		}
-
-		src := filepath.Join(config.ConfigPath, entry.Name())
+	for _, entry := range files {
+		src := filepath.Join(configDir, entry.Name())
 		dst := filepath.Join(backupDir, entry.Name())
 
+		progress.Add(1)
 		data, err := os.ReadFile(src)
 		if err != nil {
 			continue
 		}
 		os.WriteFile(dst, data, 0644)
 	}
+	progress.Finish()
 
 	log.Printf("Config backed up to: %s", backupDir)
 	return nil
 }
 
+// configBackupFiles lists the files backupConfig copies: the top-level files
+// of the config folder, without hidden ones
+func configBackupFiles(configDir string) ([]os.DirEntry, error) {
+	entries, err := os.ReadDir(configDir)
+	if err != nil {
+		return nil, err
+	}
+	var files []os.DirEntry
+	for _, entry := range entries {
+		if !entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			files = append(files, entry)
+		}
+	}
+	return files, nil
+}
+
 // calculateSHA256 calculates SHA256 hash of a file
 func calculateSHA256(filepath string) (string, error) {
 	f, err := os.Open(filepath)
@@ -648,6 +1319,136 @@ func calculateSHA256(filepath string) (string, error) {
 	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
+// artifactURL returns the download URL for a version on the primary source
+func artifactURL(info *VersionInfo, version string) string {
+	return resolveAppURL(artifactRef(info, version))
+}
+
+// artifactRef returns the archive reference of a version. Without an explicit
+// URL in version.json the latest version is served as ltth_latest.zip and
+// older versions from the archive folder.
+func artifactRef(info *VersionInfo, version string) string {
+	if artifact, ok := info.Artifacts[version]; ok && artifact.URL != "" {
+		return artifact.URL
+	}
+	if version == info.Version {
+		return "ltth_latest.zip"
+	}
+	return "archive/ltth_" + version + ".zip"
+}
+
+// resolveAppURL resolves a manifest reference that is absolute or relative to AppZIPBaseURL
+func resolveAppURL(ref string) string {
+	if strings.HasPrefix(ref, "https://") {
+		return ref
+	}
+	return AppZIPBaseURL + strings.TrimPrefix(ref, "/")
+}
+
+// isValidVersionName reports whether version is safe to use as a folder name
+func isValidVersionName(version string) bool {
+	if version == "" || version == "." || version == ".." || len(version) > 64 {
+		return false
+	}
+	for _, c := range version {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '.' || c == '-' || c == '+') {
+			return false
+		}
+	}
+	return true
+}
+
+// errNoChecksum means version.json publishes no SHA256 for an archive, which
+// is never installed unverified
+var errNoChecksum = errors.New("no checksum published")
+
+// verifyArtifact checks size and SHA256 (computed during the download) of a
+// downloaded archive against version.json
+func verifyArtifact(info *VersionInfo, path, sum, version string) error {
+	artifact := info.Artifacts[version]
+	if artifact.SHA256 == "" {
+		return fmt.Errorf("version %s: %w", version, errNoChecksum)
+	}
+
+	if err := verifyDownload(path, artifact.Size, sum, artifact.SHA256); err != nil {
+		return err
+	}
+	log.Printf("Checksum verified for version %s: %s", version, artifact.SHA256)
+	return nil
+}
+
+// verifyDigest compares a file with an expected size (skipped if 0) and SHA256
+func verifyDigest(path string, size int64, expected string) error {
+	if err := checkFileSize(path, size); err != nil {
+		return err
+	}
+	sum, err := calculateSHA256(path)
+	if err != nil {
+		return err
+	}
+	return matchDigest(sum, expected)
+}
+
+// verifyDownload compares a downloaded file with an expected size (skipped if
+// 0) and SHA256, using the sum computed while it was downloaded
+func verifyDownload(path string, size int64, sum, expected string) error {
+	if err := checkFileSize(path, size); err != nil {
+		return err
+	}
+	return matchDigest(sum, expected)
+}
+
+// checkFileSize fails unless the file has the expected size (skipped if 0)
+func checkFileSize(path string, size int64) error {
+	if size <= 0 {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.Size() != size {
+		return fmt.Errorf("size mismatch: expected %d bytes, got %d", size, info.Size())
+	}
+	return nil
+}
+
+// matchDigest compares two hex encoded SHA256 sums
+func matchDigest(sum, expected string) error {
+	if !strings.EqualFold(sum, expected) {
+		return fmt.Errorf("checksum mismatch: expected %s, got %s", expected, sum)
+	}
+	return nil
+}
+
+// errorResponse builds a failed IPC result; the UI localizes it via errorCode
+func errorResponse(code string, err error) string {
+	data, _ := json.Marshal(map[string]interface{}{
+		"success":   false,
+		"error":     err.Error(),
+		"errorCode": code,
+	})
+	return string(data)
+}
+
+// failureResponse builds the IPC result of a failed operation without error code
+func failureResponse(message string) string {
+	data, _ := json.Marshal(map[string]interface{}{
+		"success": false,
+		"error":   message,
+	})
+	return string(data)
+}
+
+// versionResponse builds the IPC result of an operation that installed or activated version
+func versionResponse(version string) string {
+	data, _ := json.Marshal(map[string]interface{}{
+		"success": true,
+		"version": version,
+	})
+	return string(data)
+}
+
 
 
 // Embedded HTML UI
@@ -702,6 +1503,10 @@ body {
 .path-row { display: flex; gap: 8px; }
 .path-input { flex: 1; padding: 12px 16px; background: var(--color-surface); border: 1px solid var(--color-border); border-radius: var(--radius-md); color: var(--color-text); font-size: 13px; }
 .path-input:focus { outline: none; border-color: var(--color-primary); }
+.select-input { width: 100%; padding: 10px 12px; background: var(--color-bg); border: 1px solid var(--color-border); border-radius: var(--radius-md); color: var(--color-text); font-size: 13px; }
+.select-input:focus { outline: none; border-color: var(--color-primary); }
+.notice { font-size: 12px; color: var(--color-warning); margin-bottom: 16px; }
+.launcher-notice { display: flex; align-items: center; justify-content: space-between; gap: 12px; margin-top: -8px; }
 
 .status-box { background: var(--color-surface); border: 1px solid var(--color-border); border-radius: var(--radius-lg); padding: 24px; margin-bottom: 24px; }
 .status-row { display: flex; align-items: center; gap: 16px; }
@@ -768,6 +1573,19 @@ body {
 .changelog-list li:last-child { border-bottom: none; }
 .changelog-list li::before { content: '→'; position: absolute; left: 0; color: var(--color-primary); }
 
+.version-list { list-style: none; }
+.version-item { border: 1px solid var(--color-border); border-radius: var(--radius-md); margin-bottom: 8px; background: var(--color-bg); }
+.version-item-header { display: flex; align-items: center; gap: 12px; padding: 10px 12px; cursor: pointer; }
+.version-item-info { flex: 1; }
+.version-item-title { font-weight: 600; display: flex; align-items: center; gap: 6px; }
+.version-item-date { font-size: 12px; color: var(--color-text-muted); }
+.version-item .changelog-list { padding: 0 12px 8px; display: none; }
+.version-item.open .changelog-list { display: block; }
+.version-item .btn { padding: 6px 12px; font-size: 12px; }
+.badge { font-size: 10px; font-weight: 600; padding: 2px 6px; border-radius: 4px; background: var(--color-surface-hover); color: var(--color-text-secondary); text-transform: uppercase; }
+.badge.active { background: rgba(18,161,22,0.2); color: var(--color-success); }
+.version-list-message { padding: 12px; text-align: center; color: var(--color-text-secondary); }
+
 .hidden { display: none !important; }
 </style>
 </head>
@@ -824,6 +1642,11 @@ body {
                 <div class="progress-text" id="progressText">0%</div>
             </div>
             
+            <div class="notice launcher-notice hidden" id="launcherNotice">
+                <span id="launcherNoticeText"></span>
+                <button class="btn btn-secondary" id="launcherUpdateBtn"></button>
+            </div>
+            
             <div class="btn-row">
                 <button class="btn btn-secondary" id="checkBtn" data-i18n="buttons.checkNow">Jetzt prüfen</button>
                 <button class="btn btn-success hidden" id="updateBtn" data-i18n="buttons.installUpdate">Update installieren</button>
@@ -832,6 +1655,7 @@ body {
             
             <div class="secondary-row">
                 <button class="btn btn-ghost" id="settingsBtn">⚙️ <span data-i18n="buttons.settings">Einstellungen</span></button>
+                <button class="btn btn-ghost" id="versionsBtn">🗂️ <span data-i18n="buttons.versions">Versionen</span></button>
                 <button class="btn btn-ghost" id="logsBtn">📄 <span data-i18n="buttons.logs">Logs</span></button>
             </div>
             
@@ -861,6 +1685,7 @@ body {
             <button class="modal-close" id="closeUpdateModal">×</button>
         </div>
         <div class="modal-body">
+            <p class="notice hidden" id="downgradeNotice" data-i18n="update.downgradeNote">Die installierte Version ist neuer als die aktuelle Version dieses Kanals.</p>
             <div class="version-compare">
                 <div class="version-box">
                     <span class="version-box-label" data-i18n="update.currentVersion">Aktuelle Version</span>
@@ -884,6 +1709,24 @@ body {
     </div>
 </div>
 
+<!-- Versions Modal -->
+<div class="modal" id="versionsModal">
+    <div class="modal-content">
+        <div class="modal-header">
+            <h2 data-i18n="versions.title">Versionen</h2>
+            <button class="modal-close" id="closeVersionsModal">×</button>
+        </div>
+        <div class="modal-body">
+            <ul class="version-list" id="versionList"></ul>
+        </div>
+        <div class="modal-footer">
+            <button class="btn btn-ghost" id="installFileBtn">📦 <span data-i18n="buttons.installFile">Aus Datei installieren</span></button>
+            <button class="btn btn-ghost" id="cleanupBtn">🧹 <span data-i18n="buttons.cleanup">Speicher freigeben</span></button>
+            <button class="btn btn-primary" id="closeVersionsBtn" data-i18n="buttons.close">Schließen</button>
+        </div>
+    </div>
+</div>
+
 <!-- Settings Modal -->
 <div class="modal" id="settingsModal">
     <div class="modal-content">
@@ -900,6 +1743,73 @@ body {
                 <label class="path-label" data-i18n="settings.configPath">Konfigurationspfad</label>
                 <p class="path-desc" id="settingsConfigPath">-</p>
             </div>
+            <div class="path-group">
+                <label class="path-label" data-i18n="settings.launcherVersion">Launcher-Version</label>
+                <p class="path-desc" id="settingsLauncherVersion">-</p>
+            </div>
+            <div class="path-group">
+                <label class="path-label" data-i18n="settings.channel">Update-Kanal</label>
+                <p class="path-desc" data-i18n="settings.channelDesc">Beta und Nightly erhalten neue Versionen früher, können aber Fehler enthalten.</p>
+                <select class="select-input" id="channelSelect">
+                    <option value="stable" data-i18n="channels.stable">Stable</option>
+                    <option value="beta" data-i18n="channels.beta">Beta</option>
+                    <option value="nightly" data-i18n="channels.nightly">Nightly</option>
+                </select>
+            </div>
+            <div class="path-group">
+                <label class="path-label" data-i18n="settings.updateSource">Update-Quelle</label>
+                <p class="path-desc" data-i18n="settings.updateSourceDesc">Woher der Launcher erfährt, welche Versionen es gibt.</p>
+                <select class="select-input" id="updateSourceSelect">
+                    <option value="feed" data-i18n="sources.feed">ltth.app (version.json)</option>
+                    <option value="github" data-i18n="sources.github">GitHub Releases</option>
+                </select>
+            </div>
+            <div class="path-group">
+                <label class="path-label" data-i18n="settings.proxy">Proxy</label>
+                <p class="path-desc" data-i18n="settings.proxyDesc">Leer = Proxy aus den Umgebungsvariablen, „direct“ = ohne Proxy. Zusätzliche CA-Zertifikate werden in der config.json eingetragen.</p>
+                <div class="path-row">
+                    <input type="text" class="select-input" id="proxyInput" placeholder="http://proxy.example.org:8080">
+                    <button class="btn btn-secondary" id="testConnectionBtn" data-i18n="buttons.testConnection">Verbindung testen</button>
+                </div>
+                <p class="path-desc" id="connectionResult"></p>
+            </div>
+            <div class="path-group">
+                <label class="path-label" data-i18n="settings.downloads">Downloads</label>
+                <p class="path-desc" data-i18n="settings.downloadsDesc">Begrenzt die Geschwindigkeit, damit ein laufender Stream nicht gestört wird (KiB/s, 0 = unbegrenzt).</p>
+                <div class="path-row">
+                    <label class="path-desc" for="rateLimitInput" data-i18n="settings.rateLimit">Updates</label>
+                    <input type="number" min="0" class="select-input" id="rateLimitInput">
+                    <label class="path-desc" for="backgroundRateLimitInput" data-i18n="settings.backgroundRateLimit">Hintergrund</label>
+                    <input type="number" min="0" class="select-input" id="backgroundRateLimitInput">
+                </div>
+                <label class="toggle-label">
+                    <input type="checkbox" id="backgroundUpdatesCheck">
+                    <span class="checkmark"></span>
+                    <span data-i18n="settings.backgroundUpdates">Updates im Hintergrund herunterladen</span>
+                </label>
+                <div class="path-row">
+                    <label class="path-desc" for="windowStartInput" data-i18n="settings.windowStart">Von</label>
+                    <input type="time" class="select-input" id="windowStartInput">
+                    <label class="path-desc" for="windowEndInput" data-i18n="settings.windowEnd">Bis</label>
+                    <input type="time" class="select-input" id="windowEndInput">
+                </div>
+                <p class="path-desc" id="downloadSettingsResult"></p>
+            </div>
+            <div class="path-group">
+                <label class="path-label" data-i18n="settings.retention">Alte Versionen</label>
+                <p class="path-desc" data-i18n="settings.retentionDesc">Behalten werden die aktive Version, angeheftete Versionen, die neuesten N Versionen und alle, die in den letzten X Tagen installiert wurden.</p>
+                <div class="path-row">
+                    <label class="path-desc" for="keepVersionsInput" data-i18n="settings.keepVersions">Versionen behalten</label>
+                    <input type="number" min="0" class="select-input" id="keepVersionsInput">
+                    <label class="path-desc" for="keepDaysInput" data-i18n="settings.keepDays">Tage behalten</label>
+                    <input type="number" min="0" class="select-input" id="keepDaysInput">
+                </div>
+                <label class="toggle-label">
+                    <input type="checkbox" id="autoCleanupCheck">
+                    <span class="checkmark"></span>
+                    <span data-i18n="settings.autoCleanup">Nach Updates automatisch aufräumen</span>
+                </label>
+            </div>
         </div>
         <div class="modal-footer">
             <button class="btn btn-primary" id="closeSettingsBtn" data-i18n="buttons.close">Schließen</button>
@@ -912,27 +1822,43 @@ body {
 const locales = {
     de: {
         setup: { title: "Willkommen beim LTTH Launcher", installPath: "Installationspfad", installPathDesc: "Hier werden die Programmdateien und Versionen gespeichert.", configPath: "Konfigurationspfad", configPathDesc: "Hier werden deine persönlichen Einstellungen gespeichert.", browse: "Durchsuchen...", continue: "Weiter", pathRequired: "Bitte wähle gültige Pfade aus." },
-        main: { checkingUpdates: "Prüfe auf Updates...", upToDate: "Auf dem neuesten Stand", updateAvailable: "Update verfügbar", noVersion: "Keine Version installiert", ready: "Bereit zum Starten", version: "Version" },
-        buttons: { checkNow: "Jetzt prüfen", installUpdate: "Update installieren", settings: "Einstellungen", logs: "Logs", start: "Starten", later: "Später", installNow: "Jetzt installieren", close: "Schließen" },
-        settings: { title: "Einstellungen", autoUpdate: "Automatische Updates beim Start", installPath: "Installationspfad", configPath: "Konfigurationspfad" },
-        update: { title: "Update verfügbar", currentVersion: "Aktuelle Version", newVersion: "Neue Version", changelog: "Änderungen" },
-        progress: { download: "Herunterladen...", extract: "Entpacken...", complete: "Fertig!" },
-        errors: { network: "Netzwerkfehler", launch: "Start fehlgeschlagen" }
+        main: { checkingUpdates: "Prüfe auf Updates...", upToDate: "Auf dem neuesten Stand", updateAvailable: "Update verfügbar", noVersion: "Keine Version installiert", ready: "Bereit zum Starten", version: "Version", offline: "Keine Verbindung – zuletzt aktualisiert", staged: "Update bereit" },
+        buttons: { checkNow: "Jetzt prüfen", installUpdate: "Update installieren", settings: "Einstellungen", logs: "Logs", start: "Starten", later: "Später", installNow: "Jetzt installieren", close: "Schließen", versions: "Versionen", install: "Installieren", activate: "Aktivieren", check: "Prüfen", pin: "Anheften", unpin: "Lösen", cleanup: "Speicher freigeben", installFile: "Aus Datei installieren", testConnection: "Verbindung testen", applyUpdate: "Update anwenden", applyNow: "Jetzt anwenden" },
+        versions: { title: "Versionen", loading: "Lade Versionen...", empty: "Keine Versionen gefunden", active: "Aktiv", installed: "Installiert", latest: "Neueste", incompatible: "Benötigt Launcher", pinned: "Angeheftet", offline: "Keine Verbindung – es werden nur installierte Versionen angezeigt. Neue Versionen lassen sich über „Aus Datei installieren“ hinzufügen." },
+        settings: { title: "Einstellungen", autoUpdate: "Automatische Updates beim Start", installPath: "Installationspfad", configPath: "Konfigurationspfad", channel: "Update-Kanal", channelDesc: "Beta und Nightly erhalten neue Versionen früher, können aber Fehler enthalten.", updateSource: "Update-Quelle", updateSourceDesc: "Woher der Launcher erfährt, welche Versionen es gibt.", proxy: "Proxy", proxyDesc: "Leer = Proxy aus den Umgebungsvariablen, „direct“ = ohne Proxy. Zusätzliche CA-Zertifikate werden in der config.json eingetragen.", connectionTesting: "Teste Verbindung...", connectionOk: "Verbindung erfolgreich", connectionFailed: "Verbindung fehlgeschlagen", connectionVia: "über", downloads: "Downloads", downloadsDesc: "Begrenzt die Geschwindigkeit, damit ein laufender Stream nicht gestört wird (KiB/s, 0 = unbegrenzt).", rateLimit: "Updates", backgroundRateLimit: "Hintergrund", backgroundUpdates: "Updates im Hintergrund herunterladen", windowStart: "Von", windowEnd: "Bis", launcherVersion: "Launcher-Version", retention: "Alte Versionen", retentionDesc: "Behalten werden die aktive Version, angeheftete Versionen, die Versionen für den Rollback, die neuesten N Versionen und alle, die in den letzten X Tagen installiert wurden.", keepVersions: "Versionen behalten", keepDays: "Tage behalten", autoCleanup: "Nach Updates automatisch aufräumen" },
+        cleanup: { scanning: "Berechne Speicherbedarf...", running: "Entferne alte Versionen...", nothing: "Es gibt keine Versionen, die entfernt werden können.", preview: "Folgende Versionen werden entfernt:", reclaimed: "Freigegebener Speicher", confirm: "Fortfahren?", done: "{count} Versionen entfernt, {size} freigegeben." },
+        repair: { ok: "Die Installation ist vollständig und unverändert.", broken: "Die Installation ist beschädigt:", modified: "geändert", missing: "fehlen", extra: "zusätzliche Dateien (bleiben erhalten)", noManifest: "Für diese Version gibt es keine Dateiliste, sie kann nur komplett neu installiert werden.", confirm: "Jetzt reparieren?", done: "Die Installation wurde repariert." },
+        launcher: { available: "Launcher-Update verfügbar:", required: "Die neue Version benötigt Launcher", staged: "Launcher-Update bereit:", update: "Launcher aktualisieren", restart: "Neu starten" },
+        channels: { stable: "Stable", beta: "Beta", nightly: "Nightly" },
+        sources: { feed: "ltth.app (version.json)", github: "GitHub Releases" },
+        update: { title: "Update verfügbar", currentVersion: "Aktuelle Version", newVersion: "Neue Version", changelog: "Änderungen", downgradeTitle: "Wechsel auf stabilere Version", downgrade: "Stabile Version", downgradeNote: "Die installierte Version ist neuer als die aktuelle Version dieses Kanals. Die stabile Version wird parallel installiert, deine Konfiguration wird vorher gesichert und die bisherige Version bleibt für ein Rollback erhalten." },
+        progress: { download: "Herunterladen...", verify: "Prüfe Download...", backup: "Sichere Konfiguration...", extract: "Entpacken...", check: "Prüfe Dateien...", complete: "Fertig!", files: "Dateien", remaining: "noch" },
+        preflight: { notWritable: "In {path} kann nicht geschrieben werden. Bitte Berechtigungen prüfen oder einen anderen Ordner wählen.", diskSpace: "Nicht genug Speicherplatz für {path}: benötigt {required}, frei {available}.", pathTooLong: "Der Installationspfad {path} ist zu lang ({required} von {available} Zeichen). Bitte einen kürzeren Pfad wählen." },
+        errors: { network: "Netzwerkfehler", launch: "Start fehlgeschlagen", install: "Installation fehlgeschlagen", checksum: "Der Download ist beschädigt oder unvollständig (Prüfsumme stimmt nicht). Bitte erneut versuchen.", signature: "Die Signatur des Downloads ist ungültig. Die Installation wurde aus Sicherheitsgründen abgebrochen.", unsafeArchive: "Das Archiv enthält unzulässige Einträge oder überschreitet die Größengrenzen. Die Installation wurde aus Sicherheitsgründen abgebrochen.", launcherTooOld: "Diese Version benötigt einen neueren Launcher. Bitte aktualisiere zuerst den Launcher.", launcherUpdate: "Launcher-Update fehlgeschlagen", repair: "Einige Dateien konnten nicht repariert werden. Bitte die Version neu installieren.", invalidVersion: "Ungültige Versionsangabe.", notInstalled: "Diese Version ist nicht installiert.", proxy: "Ungültige Proxy-Adresse, z. B. http://proxy.example.org:8080.", certificate: "Das Zertifikat des Servers wird nicht vertraut. Hinter einem Proxy mit TLS-Prüfung muss dessen CA-Zertifikat unter network.caCertificates in der config.json eingetragen werden.", downloadWindow: "Ungültige Uhrzeit, erwartet wird HH:MM." }
     },
     en: {
         setup: { title: "Welcome to LTTH Launcher", installPath: "Installation Path", installPathDesc: "This is where program files and versions will be stored.", configPath: "Configuration Path", configPathDesc: "This is where your personal settings will be stored.", browse: "Browse...", continue: "Continue", pathRequired: "Please select valid paths." },
-        main: { checkingUpdates: "Checking for updates...", upToDate: "Up to date", updateAvailable: "Update available", noVersion: "No version installed", ready: "Ready to start", version: "Version" },
-        buttons: { checkNow: "Check Now", installUpdate: "Install Update", settings: "Settings", logs: "Logs", start: "Start", later: "Later", installNow: "Install Now", close: "Close" },
-        settings: { title: "Settings", autoUpdate: "Automatic updates on startup", installPath: "Installation Path", configPath: "Configuration Path" },
-        update: { title: "Update Available", currentVersion: "Current Version", newVersion: "New Version", changelog: "Changes" },
-        progress: { download: "Downloading...", extract: "Extracting...", complete: "Complete!" },
-        errors: { network: "Network error", launch: "Launch failed" }
+        main: { checkingUpdates: "Checking for updates...", upToDate: "Up to date", updateAvailable: "Update available", noVersion: "No version installed", ready: "Ready to start", version: "Version", offline: "No connection – last updated", staged: "Update ready" },
+        buttons: { checkNow: "Check Now", installUpdate: "Install Update", settings: "Settings", logs: "Logs", start: "Start", later: "Later", installNow: "Install Now", close: "Close", versions: "Versions", install: "Install", activate: "Activate", check: "Verify", pin: "Pin", unpin: "Unpin", cleanup: "Free up space", installFile: "Install from file", testConnection: "Test connection", applyUpdate: "Apply update", applyNow: "Apply now" },
+        versions: { title: "Versions", loading: "Loading versions...", empty: "No versions found", active: "Active", installed: "Installed", latest: "Latest", incompatible: "Requires launcher", pinned: "Pinned", offline: "No connection – only installed versions are shown. New versions can be added with \"Install from file\"." },
+        settings: { title: "Settings", autoUpdate: "Automatic updates on startup", installPath: "Installation Path", configPath: "Configuration Path", channel: "Update channel", channelDesc: "Beta and Nightly get new versions earlier but may contain bugs.", updateSource: "Update source", updateSourceDesc: "Where the launcher learns which versions exist.", proxy: "Proxy", proxyDesc: "Empty = proxy from environment variables, \"direct\" = no proxy. Additional CA certificates are set in config.json.", connectionTesting: "Testing connection...", connectionOk: "Connection successful", connectionFailed: "Connection failed", connectionVia: "via", downloads: "Downloads", downloadsDesc: "Limits the speed so a running stream isn't affected (KiB/s, 0 = unlimited).", rateLimit: "Updates", backgroundRateLimit: "Background", backgroundUpdates: "Download updates in the background", windowStart: "From", windowEnd: "To", launcherVersion: "Launcher version", retention: "Old versions", retentionDesc: "Kept are the active version, pinned versions, the versions offered for rollback, the newest N versions and everything installed within the last X days.", keepVersions: "Versions to keep", keepDays: "Days to keep", autoCleanup: "Clean up automatically after updates" },
+        cleanup: { scanning: "Calculating disk usage...", running: "Removing old versions...", nothing: "There are no versions that can be removed.", preview: "The following versions will be removed:", reclaimed: "Space reclaimed", confirm: "Continue?", done: "{count} versions removed, {size} reclaimed." },
+        repair: { ok: "The installation is complete and unmodified.", broken: "The installation is damaged:", modified: "modified", missing: "missing", extra: "extra files (kept)", noManifest: "There is no file list for this version, it can only be reinstalled completely.", confirm: "Repair now?", done: "The installation has been repaired." },
+        launcher: { available: "Launcher update available:", required: "The new version requires launcher", staged: "Launcher update ready:", update: "Update launcher", restart: "Restart" },
+        channels: { stable: "Stable", beta: "Beta", nightly: "Nightly" },
+        sources: { feed: "ltth.app (version.json)", github: "GitHub Releases" },
+        update: { title: "Update Available", currentVersion: "Current Version", newVersion: "New Version", changelog: "Changes", downgradeTitle: "Switch to a more stable version", downgrade: "Stable version", downgradeNote: "The installed version is newer than the current release of this channel. The stable version is installed side by side, your configuration is backed up first and the previous version stays available for rollback." },
+        progress: { download: "Downloading...", verify: "Verifying download...", backup: "Backing up configuration...", extract: "Extracting...", check: "Checking files...", complete: "Complete!", files: "files", remaining: "remaining" },
+        preflight: { notWritable: "Cannot write to {path}. Please check the permissions or choose another folder.", diskSpace: "Not enough disk space for {path}: {required} needed, {available} free.", pathTooLong: "The installation path {path} is too long ({required} of {available} characters). Please choose a shorter path." },
+        errors: { network: "Network error", launch: "Launch failed", install: "Installation failed", checksum: "The download is corrupted or incomplete (checksum mismatch). Please try again.", signature: "The download signature is invalid. Installation was aborted for security reasons.", unsafeArchive: "The archive contains disallowed entries or exceeds the size limits. Installation was aborted for security reasons.", launcherTooOld: "This version requires a newer launcher. Please update the launcher first.", launcherUpdate: "Launcher update failed", repair: "Some files could not be repaired. Please reinstall this version.", invalidVersion: "Invalid version.", notInstalled: "This version is not installed.", proxy: "Invalid proxy address, e.g. http://proxy.example.org:8080.", certificate: "The server certificate is not trusted. Behind a TLS inspecting proxy add its CA certificate under network.caCertificates in config.json.", downloadWindow: "Invalid time, expected HH:MM." }
     }
 };
 
 let lang = 'de';
 let config = {};
 let updateInfo = null;
+let launcherInfo = null;
+let installRunning = false;
 
 function t(key) {
     const keys = key.split('.');
@@ -985,8 +1911,15 @@ async function checkUpdates() {
         if (!result.success) throw new Error(result.error);
         
         updateInfo = result;
+        document.getElementById('updateBtn').textContent = t(result.staged ? 'buttons.applyUpdate' : 'buttons.installUpdate');
         
-        if (result.updateAvailable) {
+        if (result.downgrade) {
+            updateStatus('downgrade', result.latestVersion);
+            document.getElementById('updateBtn').classList.remove('hidden');
+        } else if (result.staged) {
+            updateStatus('staged', result.latestVersion);
+            document.getElementById('updateBtn').classList.remove('hidden');
+        } else if (result.updateAvailable) {
             updateStatus('update', result.latestVersion);
             document.getElementById('updateBtn').classList.remove('hidden');
         } else if (result.currentVersion) {
@@ -996,7 +1929,13 @@ async function checkUpdates() {
             document.getElementById('updateBtn').classList.remove('hidden');
         }
         
+        if (result.offline) {
+            const subtitle = document.getElementById('statusSubtitle');
+            subtitle.textContent = [subtitle.textContent, offlineNotice(result.fetchedAt)].filter(Boolean).join(' · ');
+        }
+        
         document.getElementById('startBtn').disabled = !result.currentVersion;
+        checkLauncherUpdate();
     } catch (e) {
         updateStatus('error', e.message);
     }
@@ -1004,6 +1943,73 @@ async function checkUpdates() {
     document.getElementById('checkBtn').disabled = false;
 }
 
+// offlineNotice describes the age of cached update data, e.g. "last updated 3 hours ago"
+function offlineNotice(fetchedAt) {
+    const minutes = Math.round((fetchedAt - Date.now()) / 60000);
+    const format = new Intl.RelativeTimeFormat(config.language || 'de', { numeric: 'auto' });
+    let age;
+    if (Math.abs(minutes) < 60) age = format.format(minutes, 'minute');
+    else if (Math.abs(minutes) < 48 * 60) age = format.format(Math.round(minutes / 60), 'hour');
+    else age = format.format(Math.round(minutes / 1440), 'day');
+    return t('main.offline') + ' ' + age;
+}
+
+async function checkLauncherUpdate() {
+    const result = JSON.parse(await window.checkLauncherUpdate());
+    launcherInfo = result.success ? result : null;
+    renderLauncherNotice();
+}
+
+function renderLauncherNotice() {
+    const notice = document.getElementById('launcherNotice');
+    const text = document.getElementById('launcherNoticeText');
+    const btn = document.getElementById('launcherUpdateBtn');
+    
+    if (launcherInfo && launcherInfo.stagedVersion) {
+        text.textContent = t('launcher.staged') + ' ' + launcherInfo.stagedVersion;
+        btn.textContent = t('launcher.restart');
+        btn.onclick = restartLauncher;
+    } else if (launcherInfo && launcherInfo.updateAvailable) {
+        text.textContent = updateInfo && updateInfo.launcherRequired
+            ? t('launcher.required') + ' ' + launcherInfo.latestVersion
+            : t('launcher.available') + ' ' + launcherInfo.latestVersion;
+        btn.textContent = t('launcher.update');
+        btn.onclick = installLauncherUpdate;
+    } else {
+        notice.classList.add('hidden');
+        return;
+    }
+    notice.classList.remove('hidden');
+}
+
+async function installLauncherUpdate() {
+    showProgress(true);
+    document.querySelectorAll('.btn').forEach(b => b.disabled = true);
+    
+    installRunning = true;
+    setProgress(0, t('progress.download'));
+    
+    const result = await runInstall(() => window.installLauncherUpdate());
+    installRunning = false;
+    
+    if (result.success) {
+        setProgress(100, t('progress.complete'));
+        launcherInfo.stagedVersion = result.version;
+        renderLauncherNotice();
+    } else {
+        updateStatus('installError', t('errors.launcherUpdate') + ': ' + (result.errorCode && result.errorCode !== 'launcherUpdate' ? t('errors.' + result.errorCode) : result.error));
+    }
+    
+    showProgress(false);
+    document.querySelectorAll('.btn').forEach(b => b.disabled = false);
+    document.getElementById('startBtn').disabled = !config.lastVersion;
+}
+
+async function restartLauncher() {
+    const result = JSON.parse(await window.restartLauncher());
+    if (!result.success) alert(result.error);
+}
+
 function updateStatus(status, detail) {
     const icon = document.getElementById('statusIcon');
     const title = document.getElementById('statusTitle');
@@ -1028,6 +2034,16 @@ function updateStatus(status, detail) {
             title.textContent = t('main.updateAvailable');
             subtitle.textContent = t('update.newVersion') + ': ' + detail;
             break;
+        case 'staged':
+            icon.textContent = '📦';
+            title.textContent = t('main.staged');
+            subtitle.textContent = t('update.newVersion') + ': ' + detail;
+            break;
+        case 'downgrade':
+            icon.textContent = '↩️';
+            title.textContent = t('update.downgradeTitle');
+            subtitle.textContent = t('update.downgrade') + ': ' + detail;
+            break;
         case 'noVersion':
             icon.textContent = '⚠️';
             title.textContent = t('main.noVersion');
@@ -1043,6 +2059,11 @@ function updateStatus(status, detail) {
             title.textContent = t('errors.network');
             subtitle.textContent = detail || '';
             break;
+        case 'installError':
+            icon.textContent = '❌';
+            title.textContent = t('errors.install');
+            subtitle.textContent = detail || '';
+            break;
     }
 }
 
@@ -1056,6 +2077,62 @@ function setProgress(percent, text) {
     document.getElementById('progressText').textContent = text || percent + '%';
 }
 
+function errorMessage(result) {
+    if (result.errorCode === 'preflight' && result.issues) {
+        return result.issues.map(issue => t('preflight.' + issue.code)
+            .replace('{path}', issue.path)
+            .replace('{required}', issue.code === 'pathTooLong' ? issue.required : formatBytes(issue.required))
+            .replace('{available}', issue.code === 'pathTooLong' ? issue.available : formatBytes(issue.available))).join(' ');
+    }
+    return result.errorCode ? t('errors.' + result.errorCode) : result.error;
+}
+
+function formatBytes(bytes) {
+    if (bytes >= 1024 * 1024 * 1024) return (bytes / 1024 / 1024 / 1024).toFixed(2) + ' GB';
+    if (bytes >= 1024 * 1024) return (bytes / 1024 / 1024).toFixed(1) + ' MB';
+    if (bytes >= 1024) return (bytes / 1024).toFixed(0) + ' KB';
+    return bytes + ' B';
+}
+
+function formatDuration(seconds) {
+    seconds = Math.ceil(seconds);
+    if (seconds < 60) return seconds + ' s';
+    return Math.floor(seconds / 60) + ' min ' + (seconds % 60) + ' s';
+}
+
+// A version was staged in the background and only waits to be applied
+window.onUpdateStaged = () => {
+    if (!installRunning) checkUpdates();
+};
+
+// Progress events pushed by the Go side while an install runs
+window.onLauncherProgress = (p) => {
+    if (!installRunning) return;
+    const label = t('progress.' + p.phase);
+    if (p.total < 0) {
+        setProgress(0, label);
+        return;
+    }
+    let text = label + ' ';
+    if (p.unit === 'bytes') {
+        text += formatBytes(p.done) + ' / ' + formatBytes(p.total);
+        if (p.speed > 0) text += ' · ' + formatBytes(p.speed) + '/s';
+    } else {
+        text += p.done + ' / ' + p.total + ' ' + t('progress.files');
+    }
+    if (p.eta > 1) text += ' · ' + t('progress.remaining') + ' ' + formatDuration(p.eta);
+    setProgress(Math.min(100, Math.round(p.percent)), text);
+};
+
+// runInstall starts a background install and resolves with its final result
+function runInstall(start) {
+    return new Promise(async (resolve) => {
+        window.onInstallResult = resolve;
+        const started = JSON.parse(await start());
+        if (!started.started) resolve(started);
+    });
+}
+
 async function installUpdate() {
     if (!updateInfo) return;
     
@@ -1063,9 +2140,11 @@ async function installUpdate() {
     showProgress(true);
     document.querySelectorAll('.btn').forEach(b => b.disabled = true);
     
-    setProgress(10, t('progress.download'));
+    installRunning = true;
+    setProgress(0, t('progress.download'));
     
-    const result = JSON.parse(await window.installUpdate(updateInfo.latestVersion));
+    const result = await runInstall(() => window.installUpdate(updateInfo.latestVersion));
+    installRunning = false;
     
     if (result.success) {
         setProgress(100, t('progress.complete'));
@@ -1074,11 +2153,251 @@ async function installUpdate() {
         updateStatus('upToDate');
         document.getElementById('startBtn').disabled = false;
     } else {
-        updateStatus('error', result.error);
+        updateStatus('installError', errorMessage(result));
+    }
+    
+    showProgress(false);
+    document.querySelectorAll('.btn').forEach(b => b.disabled = false);
+}
+
+async function showVersionsModal() {
+    const list = document.getElementById('versionList');
+    list.innerHTML = '';
+    const message = document.createElement('li');
+    message.className = 'version-list-message';
+    message.textContent = t('versions.loading');
+    list.appendChild(message);
+    openModal('versionsModal');
+    
+    const result = JSON.parse(await getVersionCatalogue());
+    if (!result.success) {
+        message.textContent = t('errors.network') + ': ' + result.error;
+        return;
+    }
+    if (!result.versions.length) {
+        message.textContent = result.offline ? t('versions.offline') : t('versions.empty');
+        return;
+    }
+    renderCatalogue(result.versions);
+    if (result.offline) {
+        message.textContent = result.fetchedAt > 0 ? offlineNotice(result.fetchedAt) : t('versions.offline');
+        list.insertBefore(message, list.firstChild);
+    }
+}
+
+function renderCatalogue(versions) {
+    const list = document.getElementById('versionList');
+    list.innerHTML = '';
+    
+    versions.forEach(v => {
+        const item = document.createElement('li');
+        item.className = 'version-item';
+        
+        const header = document.createElement('div');
+        header.className = 'version-item-header';
+        header.onclick = () => item.classList.toggle('open');
+        
+        const info = document.createElement('div');
+        info.className = 'version-item-info';
+        const title = document.createElement('div');
+        title.className = 'version-item-title';
+        title.textContent = v.version;
+        const addBadge = (text, cls) => {
+            const badge = document.createElement('span');
+            badge.className = 'badge' + (cls ? ' ' + cls : '');
+            badge.textContent = text;
+            title.appendChild(badge);
+        };
+        if (v.active) addBadge(t('versions.active'), 'active');
+        else if (v.installed) addBadge(t('versions.installed'));
+        if (v.latest) addBadge(t('versions.latest'));
+        if (v.pinned) addBadge('📌 ' + t('versions.pinned'));
+        const date = document.createElement('div');
+        date.className = 'version-item-date';
+        date.textContent = [v.date, v.size ? formatBytes(v.size) : ''].filter(Boolean).join(' · ');
+        info.appendChild(title);
+        info.appendChild(date);
+        header.appendChild(info);
+        
+        if (!v.compatible) {
+            const note = document.createElement('span');
+            note.className = 'version-item-date';
+            note.textContent = t('versions.incompatible') + ' ' + v.minLauncherVersion;
+            header.appendChild(note);
+        } else if (!v.installed) {
+            const btn = document.createElement('button');
+            btn.className = 'btn btn-secondary';
+            btn.textContent = t('buttons.install');
+            btn.onclick = (e) => { e.stopPropagation(); installFromCatalogue(v.version); };
+            header.appendChild(btn);
+        } else if (!v.active) {
+            const btn = document.createElement('button');
+            btn.className = 'btn btn-secondary';
+            btn.textContent = t('buttons.activate');
+            btn.onclick = (e) => { e.stopPropagation(); activateFromCatalogue(v.version); };
+            header.appendChild(btn);
+        }
+        if (v.installed && !v.active) {
+            const btn = document.createElement('button');
+            btn.className = 'btn btn-ghost';
+            btn.textContent = v.pinned ? t('buttons.unpin') : t('buttons.pin');
+            btn.onclick = async (e) => {
+                e.stopPropagation();
+                await setVersionPinned(v.version, !v.pinned);
+                config = JSON.parse(await getConfig());
+                showVersionsModal();
+            };
+            header.appendChild(btn);
+        }
+        if (v.installed) {
+            const btn = document.createElement('button');
+            btn.className = 'btn btn-ghost';
+            btn.textContent = t('buttons.check');
+            btn.onclick = (e) => { e.stopPropagation(); checkFromCatalogue(v.version); };
+            header.appendChild(btn);
+        }
+        item.appendChild(header);
+        
+        const changes = document.createElement('ul');
+        changes.className = 'changelog-list';
+        (v.changes || []).forEach(c => {
+            const li = document.createElement('li');
+            li.textContent = c;
+            changes.appendChild(li);
+        });
+        item.appendChild(changes);
+        
+        list.appendChild(item);
+    });
+}
+
+async function installFromCatalogue(version) {
+    closeModal('versionsModal');
+    showProgress(true);
+    document.querySelectorAll('.btn').forEach(b => b.disabled = true);
+    
+    installRunning = true;
+    setProgress(0, t('progress.download'));
+    
+    const result = await runInstall(() => window.installVersion(version));
+    installRunning = false;
+    
+    if (result.success) {
+        config = JSON.parse(await getConfig());
+        updateStatus('ready');
+    } else {
+        updateStatus('installError', errorMessage(result));
+    }
+    
+    showProgress(false);
+    document.querySelectorAll('.btn').forEach(b => b.disabled = false);
+    document.getElementById('startBtn').disabled = !config.lastVersion;
+    if (result.success) showVersionsModal();
+}
+
+async function installFromFile() {
+    const path = await selectArchiveFile(t('buttons.installFile'));
+    if (!path) return;
+    closeModal('versionsModal');
+    
+    const result = await runWithProgress(t('progress.verify'), () => window.installFromFile(path, false));
+    if (result.success) {
+        config = JSON.parse(await getConfig());
+        updateStatus('ready');
+        showVersionsModal();
+    } else {
+        updateStatus('installError', errorMessage(result));
     }
+}
+
+async function activateFromCatalogue(version) {
+    const result = JSON.parse(await setActiveVersion(version));
+    if (!result.success) {
+        alert(errorMessage(result));
+        return;
+    }
+    config = JSON.parse(await getConfig());
+    updateStatus('ready');
+    document.getElementById('startBtn').disabled = false;
+    showVersionsModal();
+}
+
+// runWithProgress runs a background operation with the progress bar shown
+async function runWithProgress(label, start) {
+    showProgress(true);
+    document.querySelectorAll('.btn').forEach(b => b.disabled = true);
+    installRunning = true;
+    setProgress(0, label);
+    
+    const result = await runInstall(start);
     
+    installRunning = false;
     showProgress(false);
     document.querySelectorAll('.btn').forEach(b => b.disabled = false);
+    document.getElementById('startBtn').disabled = !config.lastVersion;
+    return result;
+}
+
+async function checkFromCatalogue(version) {
+    closeModal('versionsModal');
+    const check = await runWithProgress(t('progress.check'), () => window.verifyInstallation(version));
+    if (!check.success) {
+        alert(errorMessage(check));
+        return;
+    }
+    
+    let summary;
+    if (check.ok) {
+        summary = t('repair.ok');
+        if (check.extra.length) summary += '\n' + check.extra.length + ' ' + t('repair.extra');
+        alert(summary);
+        return;
+    }
+    if (check.hasManifest) {
+        summary = t('repair.broken') + '\n' +
+            check.modified.length + ' ' + t('repair.modified') + ', ' +
+            check.missing.length + ' ' + t('repair.missing') + ', ' +
+            check.extra.length + ' ' + t('repair.extra');
+    } else {
+        summary = t('repair.noManifest');
+    }
+    if (!confirm(summary + '\n\n' + t('repair.confirm'))) return;
+    
+    const result = await runWithProgress(t('progress.check'), () => window.repairInstallation(version));
+    if (result.success) {
+        updateStatus('ready');
+        alert(t('repair.done'));
+    } else {
+        updateStatus('installError', errorMessage(result));
+    }
+}
+
+async function freeUpSpace() {
+    closeModal('versionsModal');
+    const preview = await runWithProgress(t('cleanup.scanning'), () => window.getCleanupPlan());
+    if (!preview.success) {
+        alert(preview.error);
+        return;
+    }
+    
+    const plan = preview.plan;
+    if (!plan.remove.length) {
+        alert(t('cleanup.nothing'));
+        return;
+    }
+    const lines = plan.remove.map(v => '• ' + v.version + ' (' + formatBytes(v.reclaimable) + ')');
+    const summary = t('cleanup.preview') + '\n' + lines.join('\n') + '\n\n' +
+        t('cleanup.reclaimed') + ': ' + formatBytes(plan.reclaimed);
+    if (!confirm(summary + '\n\n' + t('cleanup.confirm'))) return;
+    
+    const result = await runWithProgress(t('cleanup.running'), () => window.runCleanup());
+    if (result.success) {
+        config = JSON.parse(await getConfig());
+        alert(t('cleanup.done').replace('{count}', result.removed.length).replace('{size}', formatBytes(result.reclaimed)));
+    } else {
+        alert(result.error);
+    }
+    showVersionsModal();
 }
 
 async function launchApp() {
@@ -1108,6 +2427,8 @@ function showUpdateModal() {
     if (!updateInfo) return;
     document.getElementById('modalCurrentVersion').textContent = updateInfo.currentVersion || '-';
     document.getElementById('modalNewVersion').textContent = updateInfo.latestVersion;
+    document.getElementById('downgradeNotice').classList.toggle('hidden', !updateInfo.downgrade);
+    document.getElementById('installNowBtn').textContent = t(updateInfo.staged ? 'buttons.applyNow' : 'buttons.installNow');
     
     const list = document.getElementById('changelogList');
     list.innerHTML = '';
@@ -1152,8 +2473,79 @@ document.getElementById('startBtn').onclick = launchApp;
 document.getElementById('settingsBtn').onclick = () => {
     document.getElementById('settingsInstallPath').textContent = config.installPath || '-';
     document.getElementById('settingsConfigPath').textContent = config.configPath || '-';
+    document.getElementById('settingsLauncherVersion').textContent = config.launcherVersion || '-';
+    document.getElementById('channelSelect').value = config.channel || 'stable';
+    document.getElementById('updateSourceSelect').value = config.updateSource || 'feed';
+    document.getElementById('proxyInput').value = config.proxy || '';
+    document.getElementById('connectionResult').textContent = '';
+    document.getElementById('rateLimitInput').value = config.downloads.rateLimit;
+    document.getElementById('backgroundRateLimitInput').value = config.downloads.backgroundRateLimit;
+    document.getElementById('backgroundUpdatesCheck').checked = config.downloads.background;
+    document.getElementById('windowStartInput').value = config.downloads.windowStart || '';
+    document.getElementById('windowEndInput').value = config.downloads.windowEnd || '';
+    document.getElementById('downloadSettingsResult').textContent = '';
+    document.getElementById('keepVersionsInput').value = config.keepVersions;
+    document.getElementById('keepDaysInput').value = config.keepDays;
+    document.getElementById('autoCleanupCheck').checked = config.autoCleanup;
     openModal('settingsModal');
 };
+document.getElementById('channelSelect').onchange = async (e) => {
+    await saveConfig(JSON.stringify({ channel: e.target.value }));
+    config = JSON.parse(await getConfig());
+    document.getElementById('updateBtn').classList.add('hidden');
+    checkUpdates();
+};
+document.getElementById('updateSourceSelect').onchange = async (e) => {
+    await saveConfig(JSON.stringify({ updateSource: e.target.value }));
+    config = JSON.parse(await getConfig());
+    document.getElementById('updateBtn').classList.add('hidden');
+    checkUpdates();
+};
+document.getElementById('proxyInput').onchange = async (e) => {
+    const result = JSON.parse(await saveConfig(JSON.stringify({ proxy: e.target.value })));
+    document.getElementById('connectionResult').textContent = result.success ? '' : errorMessage(result);
+    config = JSON.parse(await getConfig());
+};
+document.getElementById('testConnectionBtn').onclick = async () => {
+    const btn = document.getElementById('testConnectionBtn');
+    const output = document.getElementById('connectionResult');
+    btn.disabled = true;
+    output.textContent = t('settings.connectionTesting');
+    const result = JSON.parse(await testConnection());
+    const via = result.proxy && result.proxy !== 'direct' ? ' ' + t('settings.connectionVia') + ' ' + result.proxy : '';
+    if (result.success) {
+        output.textContent = t('settings.connectionOk') + via + ' (' + result.durationMs + ' ms)';
+    } else {
+        output.textContent = t('settings.connectionFailed') + via + ': ' + (result.errorCode ? t('errors.' + result.errorCode) : (result.status || result.error));
+    }
+    if (result.settingsError) output.textContent += ' – ' + result.settingsError;
+    btn.disabled = false;
+};
+async function saveDownloadSettings(updates) {
+    const result = JSON.parse(await saveConfig(JSON.stringify(updates)));
+    document.getElementById('downloadSettingsResult').textContent = result.success ? '' : errorMessage(result);
+    config = JSON.parse(await getConfig());
+}
+document.getElementById('rateLimitInput').onchange = (e) => saveDownloadSettings({ rateLimit: Math.max(0, parseInt(e.target.value, 10) || 0) });
+document.getElementById('backgroundRateLimitInput').onchange = (e) => saveDownloadSettings({ backgroundRateLimit: Math.max(0, parseInt(e.target.value, 10) || 0) });
+document.getElementById('backgroundUpdatesCheck').onchange = (e) => saveDownloadSettings({ backgroundUpdates: e.target.checked });
+document.getElementById('windowStartInput').onchange = (e) => saveDownloadSettings({ windowStart: e.target.value });
+document.getElementById('windowEndInput').onchange = (e) => saveDownloadSettings({ windowEnd: e.target.value });
+document.getElementById('keepVersionsInput').onchange = async (e) => {
+    await saveConfig(JSON.stringify({ keepVersions: Math.max(0, parseInt(e.target.value, 10) || 0) }));
+    config = JSON.parse(await getConfig());
+};
+document.getElementById('keepDaysInput').onchange = async (e) => {
+    await saveConfig(JSON.stringify({ keepDays: Math.max(0, parseInt(e.target.value, 10) || 0) }));
+    config = JSON.parse(await getConfig());
+};
+document.getElementById('autoCleanupCheck').onchange = async (e) => {
+    await saveConfig(JSON.stringify({ autoCleanup: e.target.checked }));
+    config = JSON.parse(await getConfig());
+};
+document.getElementById('versionsBtn').onclick = showVersionsModal;
+document.getElementById('cleanupBtn').onclick = freeUpSpace;
+document.getElementById('installFileBtn').onclick = installFromFile;
 document.getElementById('logsBtn').onclick = () => openLogs();
 
 document.getElementById('autoUpdateCheck').onchange = async (e) => {
@@ -1163,6 +2555,8 @@ document.getElementById('autoUpdateCheck').onchange = async (e) => {
 document.getElementById('closeUpdateModal').onclick = () => closeModal('updateModal');
 document.getElementById('laterBtn').onclick = () => closeModal('updateModal');
 document.getElementById('installNowBtn').onclick = installUpdate;
+document.getElementById('closeVersionsModal').onclick = () => closeModal('versionsModal');
+document.getElementById('closeVersionsBtn').onclick = () => closeModal('versionsModal');
 document.getElementById('closeSettingsModal').onclick = () => closeModal('settingsModal');
 document.getElementById('closeSettingsBtn').onclick = () => closeModal('settingsModal');
 
@@ -1171,6 +2565,7 @@ document.querySelectorAll('.lang-btn').forEach(btn => {
         lang = btn.dataset.lang;
         await saveConfig(JSON.stringify({ language: lang }));
         applyLang();
+        renderLauncherNotice();
     };
 });
 
@@ -0,0 +1,307 @@
+/**
+ * LTTH Launcher - Install Manifests and Repair
+ *
+ * Every installed version carries .ltth-manifest.json with the relative
+ * path, size and SHA256 of each file. A version can be verified against it
+ * and repaired by downloading only the broken files through its delta file
+ * list, or else by extracting them from a fresh, verified download of its
+ * archive.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// installManifestName is the per-version file list written on install
+const installManifestName = ".ltth-manifest.json"
+
+// InstallManifest lists the files a version consists of
+type InstallManifest struct {
+	Version string         `json:"version"`
+	Files   []ManifestFile `json:"files"`
+}
+
+// ManifestFile is one file of an installed version; Path uses forward slashes
+type ManifestFile struct {
+	Path       string    `json:"path"`
+	Size       int64     `json:"size"`
+	SHA256     string    `json:"sha256"`
+	Executable bool      `json:"executable,omitempty"`
+	ModTime    time.Time `json:"modTime"`
+}
+
+// VerifyReport is the result of checking a version against its manifest
+type VerifyReport struct {
+	Version     string   `json:"version"`
+	HasManifest bool     `json:"hasManifest"`
+	OK          bool     `json:"ok"`
+	Modified    []string `json:"modified"`
+	Missing     []string `json:"missing"`
+	Extra       []string `json:"extra"`
+}
+
+// isInstallMetadata reports whether a relative path is one of the launcher's own files
+func isInstallMetadata(rel string) bool {
+	return rel == installManifestName || rel == installMarkerName
+}
+
+// listInstalledFiles returns the slash-separated paths of all files below dir,
+// not counting symbolic links
+func listInstalledFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// Symbolic links of tarballs aren't files of their own
+		if d.IsDir() || d.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !isInstallMetadata(rel) {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// writeInstallManifest records every file below dir in the manifest. Files
+// already hashed during extraction are taken from hashed, others are hashed.
+func writeInstallManifest(dir, version string, hashed []ManifestFile, report ProgressFunc) error {
+	paths, err := listInstalledFiles(dir)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]ManifestFile, len(hashed))
+	for _, file := range hashed {
+		known[file.Path] = file
+	}
+
+	progress := newProgressTracker(PhaseCheck, UnitFiles, int64(len(paths)), report)
+	manifest := InstallManifest{Version: version, Files: make([]ManifestFile, 0, len(paths))}
+	for _, rel := range paths {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if file, ok := known[rel]; ok && file.Size == info.Size() {
+			manifest.Files = append(manifest.Files, file)
+			progress.Add(1)
+			continue
+		}
+		sum, err := calculateSHA256(path)
+		if err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, ManifestFile{Path: rel, Size: info.Size(), SHA256: sum, Executable: info.Mode()&0111 != 0, ModTime: info.ModTime()})
+		progress.Add(1)
+	}
+	progress.Finish()
+
+	data, _ := json.MarshalIndent(manifest, "", "  ")
+	return os.WriteFile(filepath.Join(dir, installManifestName), data, 0644)
+}
+
+// loadInstallManifest reads the manifest of an installed version
+func loadInstallManifest(dir string) (*InstallManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, installManifestName))
+	if err != nil {
+		return nil, err
+	}
+	var manifest InstallManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid install manifest: %v", err)
+	}
+	return &manifest, nil
+}
+
+// verifyInstallation compares an installed version with its manifest
+func verifyInstallation(version string, report ProgressFunc) (*VerifyReport, error) {
+	dir := filepath.Join(currentConfig().InstallPath, version)
+	result := &VerifyReport{Version: version, Modified: []string{}, Missing: []string{}, Extra: []string{}}
+
+	manifest, err := loadInstallManifest(dir)
+	if os.IsNotExist(err) {
+		// Installed by an older launcher; only a full reinstall can repair it
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.HasManifest = true
+
+	present, err := listInstalledFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	expected := make(map[string]bool, len(manifest.Files))
+
+	progress := newProgressTracker(PhaseCheck, UnitFiles, int64(len(manifest.Files)), report)
+	for _, file := range manifest.Files {
+		expected[file.Path] = true
+		path := filepath.Join(dir, filepath.FromSlash(file.Path))
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			result.Missing = append(result.Missing, file.Path)
+		} else if verifyDigest(path, file.Size, file.SHA256) != nil {
+			result.Modified = append(result.Modified, file.Path)
+		}
+		progress.Add(1)
+	}
+	progress.Finish()
+
+	for _, rel := range present {
+		if !expected[rel] {
+			result.Extra = append(result.Extra, rel)
+		}
+	}
+
+	// Extra files are reported but don't make an installation broken
+	result.OK = len(result.Modified) == 0 && len(result.Missing) == 0
+	return result, nil
+}
+
+// performRepair restores modified and missing files of an installed version
+// from a freshly downloaded archive. Versions without a manifest are reinstalled.
+func performRepair(version string, report ProgressFunc) string {
+	if !isValidVersionName(version) || !isVersionInstalled(version) {
+		return errorResponse("notInstalled", fmt.Errorf("version %s is not installed", version))
+	}
+	info, err := loadVersionInfo()
+	if err != nil {
+		return failureResponse(err.Error())
+	}
+
+	before, err := verifyInstallation(version, report)
+	if err != nil {
+		return failureResponse(err.Error())
+	}
+	if before.OK {
+		return verifyResponse(before, 0)
+	}
+
+	broken := make(map[string]bool)
+	for _, rel := range append(before.Modified, before.Missing...) {
+		broken[rel] = true
+	}
+	repaired := false
+	if before.HasManifest {
+		// The file list of a delta update has everything to fetch single files
+		err := repairFromFileList(info, version, broken, report)
+		if err != nil && err != errNoDelta {
+			log.Printf("Repair of %s from its file list failed, using full archive: %v", version, err)
+		}
+		repaired = err == nil
+	}
+	if !repaired {
+		if failure := repairFromArchive(info, version, before.HasManifest, broken, report); failure != "" {
+			return failure
+		}
+	}
+
+	after, err := verifyInstallation(version, report)
+	if err != nil {
+		return failureResponse(err.Error())
+	}
+	if !after.OK {
+		// The archive itself doesn't match the manifest written at install time
+		log.Printf("Version %s still differs after repair: %d modified, %d missing", version, len(after.Modified), len(after.Missing))
+		return errorResponse("repair", fmt.Errorf("%d files could not be repaired", len(after.Modified)+len(after.Missing)))
+	}
+
+	log.Printf("Version %s repaired", version)
+	return verifyResponse(after, len(before.Modified)+len(before.Missing))
+}
+
+// repairFromFileList downloads the broken files of version listed in its
+// delta file list. It returns errNoDelta if the version has no file list.
+func repairFromFileList(info *VersionInfo, version string, broken map[string]bool, report ProgressFunc) error {
+	artifact := info.Artifacts[version]
+	if artifact.Files == "" {
+		return errNoDelta
+	}
+	list, fileBases, err := fetchVersionFileList(artifact, version)
+	if err != nil {
+		return err
+	}
+	listed := make(map[string]ManifestFile, len(list.Files))
+	for _, file := range list.Files {
+		listed[file.Path] = file
+	}
+
+	dir := filepath.Join(currentConfig().InstallPath, version)
+	log.Printf("Repairing %d files of version %s from its file list", len(broken), version)
+	progress := newProgressTracker(PhaseDownload, UnitFiles, int64(len(broken)), report)
+	for rel := range broken {
+		file, ok := listed[rel]
+		if !ok || !isSafeManifestPath(file.Path) {
+			return fmt.Errorf("%s is not in the file list", rel)
+		}
+		dest := filepath.Join(dir, filepath.FromSlash(file.Path))
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		if err := downloadDeltaFile(dest, file, fileBases); err != nil {
+			return fmt.Errorf("download of %s failed: %w", file.Path, err)
+		}
+		progress.Add(1)
+	}
+	progress.Finish()
+	return nil
+}
+
+// repairFromArchive downloads the archive of version and extracts the broken
+// files from it, or reinstalls the version if it has no install manifest.
+// It returns the IPC failure result, or "" on success.
+func repairFromArchive(info *VersionInfo, version string, hasManifest bool, broken map[string]bool, report ProgressFunc) string {
+	zipPath, failure := downloadArchive(info, version, report)
+	if failure != "" {
+		return failure
+	}
+	// Only the archive is removed; other partial downloads stay resumable
+	defer os.Remove(zipPath)
+
+	if !hasManifest {
+		log.Printf("Version %s has no install manifest, reinstalling it", version)
+		if err := installVersionDir(info, zipPath, version, report); err != nil {
+			return failureResponse(err.Error())
+		}
+		return ""
+	}
+	log.Printf("Repairing %d files of version %s", len(broken), version)
+	dir := filepath.Join(currentConfig().InstallPath, version)
+	if _, err := extractArchive(zipPath, dir, archiveMediaType(info, version), broken, report); err != nil {
+		return failureResponse("Repair failed: " + err.Error())
+	}
+	return ""
+}
+
+// verifyResponse builds the IPC result for a verify or repair run
+func verifyResponse(report *VerifyReport, repaired int) string {
+	data, _ := json.Marshal(map[string]interface{}{
+		"success":     true,
+		"version":     report.Version,
+		"hasManifest": report.HasManifest,
+		"ok":          report.OK,
+		"modified":    report.Modified,
+		"missing":     report.Missing,
+		"extra":       report.Extra,
+		"repaired":    repaired,
+	})
+	return string(data)
+}
@@ -0,0 +1,201 @@
+/**
+ * LTTH Launcher - Update Mirrors
+ *
+ * version.json and release archives can be fetched from several mirrors.
+ * Mirrors come from the launcher config (mirrors.metadata / .artifacts, tried
+ * first), the built-in sources and the mirrors announced in version.json.
+ * Every download is checked against the signed manifest, so any mirror is as
+ * trustworthy as the primary source.
+ *
+ * Failures are tracked per mirror; a mirror that failed recently is moved to
+ * the end of the list for a cooldown that grows with repeated failures.
+ */
+
+package main
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// mirrorRetries is the retry budget of a download while other mirrors remain
+	mirrorRetries = 2
+	// mirrorBaseCooldown and mirrorMaxCooldown bound how long a failed mirror is tried last
+	mirrorBaseCooldown = 1 * time.Minute
+	mirrorMaxCooldown  = 30 * time.Minute
+)
+
+// MirrorList is an ordered list of alternative update sources
+type MirrorList struct {
+	Metadata  []string `json:"metadata,omitempty"`
+	Artifacts []string `json:"artifacts,omitempty"`
+}
+
+// mirrorSource is one URL to try and the mirror it belongs to
+type mirrorSource struct {
+	Mirror string
+	URL    string
+}
+
+// mirrorState tracks the recent health of one mirror
+type mirrorState struct {
+	Failures    int
+	LastError   string
+	LastFailure time.Time
+	LastSuccess time.Time
+}
+
+var (
+	mirrorMu     sync.Mutex
+	mirrorStates = make(map[string]*mirrorState)
+)
+
+// recordMirrorSuccess marks a mirror as healthy again
+func recordMirrorSuccess(mirror string) {
+	mirrorMu.Lock()
+	defer mirrorMu.Unlock()
+	state := mirrorStateLocked(mirror)
+	state.Failures = 0
+	state.LastSuccess = time.Now()
+}
+
+// recordMirrorFailure counts a failed request against a mirror
+func recordMirrorFailure(mirror string, err error) {
+	mirrorMu.Lock()
+	defer mirrorMu.Unlock()
+	state := mirrorStateLocked(mirror)
+	state.Failures++
+	state.LastError = err.Error()
+	state.LastFailure = time.Now()
+	log.Printf("Mirror %s failed (%d in a row): %v", mirror, state.Failures, err)
+}
+
+func mirrorStateLocked(mirror string) *mirrorState {
+	state, ok := mirrorStates[mirror]
+	if !ok {
+		state = &mirrorState{}
+		mirrorStates[mirror] = state
+	}
+	return state
+}
+
+// mirrorCoolingDown reports whether a mirror failed too recently to be tried first
+func mirrorCoolingDown(mirror string, now time.Time) bool {
+	state, ok := mirrorStates[mirror]
+	if !ok || state.Failures == 0 {
+		return false
+	}
+	cooldown := mirrorBaseCooldown << uint(state.Failures-1)
+	if cooldown <= 0 || cooldown > mirrorMaxCooldown {
+		cooldown = mirrorMaxCooldown
+	}
+	return now.Sub(state.LastFailure) < cooldown
+}
+
+// orderByHealth moves mirrors in their cooldown to the end, keeping the order otherwise
+func orderByHealth(mirrors []string) []string {
+	mirrorMu.Lock()
+	defer mirrorMu.Unlock()
+	now := time.Now()
+	ordered := append([]string(nil), mirrors...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return !mirrorCoolingDown(ordered[i], now) && mirrorCoolingDown(ordered[j], now)
+	})
+	return ordered
+}
+
+// collectMirrors merges mirror lists in order, dropping duplicates and anything but HTTPS
+func collectMirrors(lists ...[]string) []string {
+	seen := make(map[string]bool)
+	var mirrors []string
+	for _, list := range lists {
+		for _, mirror := range list {
+			mirror = strings.TrimSpace(mirror)
+			if !strings.HasPrefix(mirror, "https://") {
+				if mirror != "" {
+					log.Printf("Ignoring mirror %q: only https:// is allowed", mirror)
+				}
+				continue
+			}
+			if !seen[mirror] {
+				seen[mirror] = true
+				mirrors = append(mirrors, mirror)
+			}
+		}
+	}
+	return mirrors
+}
+
+// manifestMirrors returns the mirrors announced by the last fetched version.json
+func manifestMirrors() MirrorList {
+	info := currentVersionInfo()
+	if info == nil || info.Mirrors == nil {
+		return MirrorList{}
+	}
+	return *info.Mirrors
+}
+
+// metadataSources lists the version.json URLs to try, healthiest first
+func metadataSources() []mirrorSource {
+	urls := orderByHealth(collectMirrors(currentConfig().Mirrors.Metadata, []string{VersionURL}, manifestMirrors().Metadata))
+	sources := make([]mirrorSource, len(urls))
+	for i, url := range urls {
+		sources[i] = mirrorSource{Mirror: url, URL: url}
+	}
+	return sources
+}
+
+// artifactBases lists the base URLs archives and file lists are relative to
+func artifactBases() []string {
+	var bases []string
+	for _, base := range collectMirrors(currentConfig().Mirrors.Artifacts, []string{AppZIPBaseURL}, manifestMirrors().Artifacts) {
+		if !strings.HasSuffix(base, "/") {
+			base += "/"
+		}
+		bases = append(bases, base)
+	}
+	return bases
+}
+
+// artifactSources lists the URLs a manifest reference can be fetched from,
+// healthiest mirror first. Absolute URLs outside the known mirrors have no
+// alternatives.
+func artifactSources(ref string) []mirrorSource {
+	bases := artifactBases()
+	rel := strings.TrimPrefix(ref, "/")
+	if strings.HasPrefix(ref, "https://") {
+		rel = ""
+		for _, base := range bases {
+			if strings.HasPrefix(ref, base) {
+				rel = strings.TrimPrefix(ref, base)
+				break
+			}
+		}
+		if rel == "" {
+			return []mirrorSource{{Mirror: ref, URL: ref}}
+		}
+	}
+
+	ordered := orderByHealth(bases)
+	sources := make([]mirrorSource, len(ordered))
+	for i, base := range ordered {
+		sources[i] = mirrorSource{Mirror: base, URL: base + rel}
+	}
+	return sources
+}
+
+// downloadFromMirror downloads url into dest and returns its SHA256; unless it
+// is the last mirror, it gives up early so the next mirror is tried quickly
+func downloadFromMirror(dest, url string, last bool, report ProgressFunc) (string, error) {
+	d := newDownloader()
+	d.Progress = report
+	if !last {
+		d.MaxRetries = mirrorRetries
+	}
+	return d.Download(context.Background(), dest, url)
+}
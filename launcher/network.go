@@ -0,0 +1,275 @@
+/**
+ * LTTH Launcher - Network Settings
+ *
+ * All HTTP traffic of the launcher (version.json, signatures, file lists,
+ * archives, GitHub API) goes through one transport configured from the
+ * network section of the launcher config:
+ *
+ *   proxy            proxy URL, "direct" for none, empty for HTTPS_PROXY /
+ *                    HTTP_PROXY / NO_PROXY from the environment
+ *   caCertificates   PEM files trusted in addition to the system roots,
+ *                    e.g. the certificate of a TLS inspecting proxy
+ *   connectTimeout   seconds to connect and finish the TLS handshake
+ *   idleTimeout      seconds without data before a request is given up
+ *   requestTimeout   seconds for a whole metadata request
+ *
+ * Every request carries "LTTH-Launcher/<version>" as user agent.
+ */
+
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// proxyDirect disables any proxy, including one set in the environment
+const proxyDirect = "direct"
+
+// NetworkSettings configures the HTTP traffic of the launcher
+type NetworkSettings struct {
+	Proxy          string   `json:"proxy,omitempty"`
+	CACertificates []string `json:"caCertificates,omitempty"`
+	ConnectTimeout int      `json:"connectTimeout"`
+	IdleTimeout    int      `json:"idleTimeout"`
+	RequestTimeout int      `json:"requestTimeout"`
+}
+
+// defaultNetworkSettings work for direct connections and most proxies
+func defaultNetworkSettings() NetworkSettings {
+	return NetworkSettings{
+		ConnectTimeout: 15,
+		IdleTimeout:    30,
+		RequestTimeout: 60,
+	}
+}
+
+// networkSettings returns the configured settings, using defaults for unset values
+func networkSettings() NetworkSettings {
+	settings, defaults := currentConfig().Network, defaultNetworkSettings()
+	if settings.ConnectTimeout <= 0 {
+		settings.ConnectTimeout = defaults.ConnectTimeout
+	}
+	if settings.IdleTimeout <= 0 {
+		settings.IdleTimeout = defaults.IdleTimeout
+	}
+	if settings.RequestTimeout <= 0 {
+		settings.RequestTimeout = defaults.RequestTimeout
+	}
+	return settings
+}
+
+var (
+	transportMu     sync.Mutex
+	sharedTransport *userAgentTransport
+)
+
+// httpClient returns a client for metadata requests, bounded by requestTimeout
+func httpClient() *http.Client {
+	return &http.Client{
+		Transport: launcherTransport(),
+		Timeout:   time.Duration(networkSettings().RequestTimeout) * time.Second,
+	}
+}
+
+// downloadClient returns a client for downloads, which may take arbitrarily
+// long as long as data keeps coming
+func downloadClient() *http.Client {
+	return &http.Client{Transport: launcherTransport()}
+}
+
+// launcherTransport returns the shared transport, building it on first use
+func launcherTransport() http.RoundTripper {
+	transportMu.Lock()
+	defer transportMu.Unlock()
+	if sharedTransport == nil {
+		transport, err := newTransport(networkSettings())
+		if err != nil {
+			log.Printf("Network settings: %v", err)
+		}
+		sharedTransport = &userAgentTransport{transport}
+	}
+	return sharedTransport
+}
+
+// resetTransport drops the shared transport so changed settings take effect
+func resetTransport() {
+	transportMu.Lock()
+	defer transportMu.Unlock()
+	if sharedTransport != nil {
+		sharedTransport.base.CloseIdleConnections()
+	}
+	sharedTransport = nil
+}
+
+// newTransport builds a transport from settings. Invalid parts are reported
+// in the error and left out, so the transport is always usable.
+func newTransport(settings NetworkSettings) (*http.Transport, error) {
+	var errs []error
+
+	proxy, err := proxyFunc(settings.Proxy)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	roots, err := certPool(settings.CACertificates)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	connect := time.Duration(settings.ConnectTimeout) * time.Second
+	return &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   connect,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig:       &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12},
+		TLSHandshakeTimeout:   connect,
+		ResponseHeaderTimeout: time.Duration(settings.IdleTimeout) * time.Second,
+		IdleConnTimeout:       90 * time.Second,
+		MaxIdleConnsPerHost:   4,
+		ForceAttemptHTTP2:     true,
+	}, errors.Join(errs...)
+}
+
+// proxyFunc returns the proxy selection for a proxy setting
+func proxyFunc(proxy string) (func(*http.Request) (*url.URL, error), error) {
+	switch proxy = strings.TrimSpace(proxy); proxy {
+	case "":
+		return http.ProxyFromEnvironment, nil
+	case proxyDirect:
+		return nil, nil
+	}
+	proxyURL, err := parseProxyURL(proxy)
+	if err != nil {
+		return http.ProxyFromEnvironment, err
+	}
+	return http.ProxyURL(proxyURL), nil
+}
+
+// parseProxyURL validates a proxy URL like http://proxy.school.local:8080
+func parseProxyURL(proxy string) (*url.URL, error) {
+	proxyURL, err := url.Parse(proxy)
+	if err != nil || proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q", proxy)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5":
+		return proxyURL, nil
+	}
+	return nil, fmt.Errorf("unsupported proxy scheme %q", proxyURL.Scheme)
+}
+
+// certPool returns the system roots plus the certificates in files
+func certPool(files []string) (*x509.CertPool, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	var errs []error
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("CA certificate: %v", err))
+			continue
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			errs = append(errs, fmt.Errorf("CA certificate %s: no PEM certificates found", file))
+		}
+	}
+	return pool, errors.Join(errs...)
+}
+
+// userAgent identifies the launcher to servers and proxies
+func userAgent() string {
+	return fmt.Sprintf("LTTH-Launcher/%s (%s; %s)", AppVersion, runtime.GOOS, runtime.GOARCH)
+}
+
+// userAgentTransport sets the launcher user agent on every request
+type userAgentTransport struct {
+	base *http.Transport
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", userAgent())
+	}
+	return t.base.RoundTrip(req)
+}
+
+// connectionTestURL returns the URL the connection test requests
+func connectionTestURL() (string, error) {
+	if currentConfig().UpdateSource == SourceGitHub {
+		return newGitHubSource().endpoint()
+	}
+	sources := metadataSources()
+	if len(sources) == 0 {
+		return "", fmt.Errorf("no update source configured")
+	}
+	return sources[0].URL, nil
+}
+
+// testConnection requests the update source with the current settings and
+// describes the outcome for the settings dialog
+func testConnection() map[string]interface{} {
+	resetTransport()
+	result := map[string]interface{}{"success": false, "userAgent": userAgent()}
+
+	target, err := connectionTestURL()
+	if err != nil {
+		result["error"] = err.Error()
+		return result
+	}
+	result["url"] = target
+
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		result["error"] = err.Error()
+		return result
+	}
+	if _, err := newTransport(networkSettings()); err != nil {
+		result["settingsError"] = err.Error()
+	}
+
+	result["proxy"] = proxyDirect
+	if proxy, _ := proxyFunc(currentConfig().Network.Proxy); proxy != nil {
+		if proxyURL, err := proxy(req); err == nil && proxyURL != nil {
+			proxyURL.User = nil
+			result["proxy"] = proxyURL.String()
+		}
+	}
+
+	start := time.Now()
+	resp, err := httpClient().Do(req)
+	result["durationMs"] = time.Since(start).Milliseconds()
+	if err != nil {
+		var certErr *tls.CertificateVerificationError
+		if errors.As(err, &certErr) {
+			result["errorCode"] = "certificate"
+		}
+		result["error"] = err.Error()
+		log.Printf("Connection test to %s failed: %v", target, err)
+		return result
+	}
+	resp.Body.Close()
+
+	result["status"] = resp.Status
+	result["success"] = resp.StatusCode == http.StatusOK
+	log.Printf("Connection test to %s: %s in %v", target, resp.Status, time.Since(start))
+	return result
+}
@@ -0,0 +1,179 @@
+/**
+ * LTTH Launcher - Offline Install
+ *
+ * A version can be installed from a local archive, e.g. one copied from a
+ * USB stick at an event without internet. Next to the archive the launcher
+ * looks for
+ *
+ *   <archive>.sig              detached signature of the archive
+ *   version.json(.sig)         signed manifest with checksum, size and
+ *                              mediaType of the version
+ *
+ * The version is taken from the manifest entry whose checksum matches the
+ * archive, otherwise from a file name like ltth_1.2.0.zip. Verification,
+ * staging and extraction are the same as for downloaded archives.
+ */
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ltth-launcher/semver"
+)
+
+// localManifestName is the manifest looked for next to a local archive
+const localManifestName = "version.json"
+
+// performFileInstall installs the local archive at path, reporting progress to
+// report. With activate set the version becomes the one launched by "Start".
+func performFileInstall(path string, activate bool, report ProgressFunc) string {
+	log.Printf("Installing from file %s...", path)
+
+	if currentConfig().InstallPath == "" || currentConfig().ConfigPath == "" {
+		return `{"success": false, "error": "Paths not configured"}`
+	}
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return failureResponse("Archive not found: " + filepath.Base(path))
+	}
+
+	manifest, err := loadLocalManifest(filepath.Dir(path))
+	if err != nil {
+		log.Printf("Local manifest rejected: %v", err)
+		return errorResponse("signature", err)
+	}
+
+	if report != nil {
+		report(ProgressEvent{Phase: PhaseVerify, Unit: UnitBytes, Total: -1})
+	}
+	sum, err := calculateSHA256(path)
+	if err != nil {
+		return failureResponse(err.Error())
+	}
+
+	version, artifact, err := identifyArchive(path, sum, manifest)
+	if err != nil {
+		return errorResponse("invalidVersion", err)
+	}
+	if artifact.SHA256 != "" {
+		if err := verifyDownload(path, artifact.Size, sum, artifact.SHA256); err != nil {
+			log.Printf("Archive verification failed: %v", err)
+			return errorResponse("checksum", err)
+		}
+		log.Printf("Checksum verified for version %s: %s", version, artifact.SHA256)
+	} else if !signatureRequired() {
+		// Without a checksum only the release signature can vouch for the file
+		log.Printf("Refusing to install %s: neither checksum nor signature to verify", version)
+		return errorResponse("checksum", fmt.Errorf("version %s: %w", version, errNoChecksum))
+	}
+	if err := verifyLocalSignature(path); err != nil {
+		log.Printf("Archive signature rejected: %v", err)
+		return errorResponse("signature", err)
+	}
+
+	// Refuse versions that need a newer launcher
+	if !launcherCompatible(artifact.MinLauncherVersion) {
+		return errorResponse("launcherTooOld", fmt.Errorf("version %s requires launcher %s (this is %s)", version, artifact.MinLauncherVersion, AppVersion))
+	}
+	if artifact.Size == 0 {
+		artifact.Size = info.Size()
+	}
+	if issues := preflightArtifact(version, artifact, false); len(issues) > 0 {
+		log.Printf("Preflight for %s failed: %+v", version, issues)
+		return preflightResponse(issues)
+	}
+
+	mediaType := artifact.MediaType
+	if mediaType == "" {
+		mediaType = mediaTypeFromName(path)
+	}
+	log.Printf("Extracting %s", path)
+	staging, err := stageArchive(path, version, mediaType, report)
+	if errors.Is(err, errUnsafeArchive) {
+		log.Printf("Archive of %s rejected: %v", version, err)
+		return errorResponse("unsafeArchive", err)
+	}
+	if err != nil {
+		log.Printf("Install of %s failed: %v", version, err)
+		return failureResponse(err.Error())
+	}
+
+	if err := commitInstall(staging, version, activate, report); err != nil {
+		log.Printf("Install of %s failed: %v", version, err)
+		return failureResponse(err.Error())
+	}
+	cleanupAfterInstall(activate)
+
+	log.Printf("Version %s installed from %s", version, path)
+	return versionResponse(version)
+}
+
+// loadLocalManifest reads and verifies the manifest in dir; it returns nil if
+// there is none
+func loadLocalManifest(dir string) (*VersionInfo, error) {
+	path := filepath.Join(dir, localManifestName)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyLocalManifestSignature(data, path); err != nil {
+		return nil, err
+	}
+	info, err := parseVersionInfo(data)
+	if err != nil {
+		return nil, err
+	}
+	applyRevocations(info.RevokedKeys)
+	return info, nil
+}
+
+// identifyArchive determines the version of a local archive and what is known
+// about it, from the local manifest, the last fetched version.json or its name
+func identifyArchive(path, sum string, manifest *VersionInfo) (string, ArtifactInfo, error) {
+	published := currentVersionInfo()
+	for _, info := range []*VersionInfo{manifest, published} {
+		if info == nil {
+			continue
+		}
+		for version, artifact := range info.Artifacts {
+			if artifact.SHA256 != "" && strings.EqualFold(artifact.SHA256, sum) && isValidVersionName(version) {
+				return version, artifact, nil
+			}
+		}
+	}
+
+	version := versionFromArchiveName(path)
+	if !isValidVersionName(version) {
+		return "", ArtifactInfo{}, fmt.Errorf("cannot tell the version of %s", filepath.Base(path))
+	}
+	if _, err := semver.ParseLoose(version); err != nil {
+		return "", ArtifactInfo{}, fmt.Errorf("cannot tell the version of %s", filepath.Base(path))
+	}
+	// A published version whose checksum didn't match is refused later on
+	for _, info := range []*VersionInfo{manifest, published} {
+		if info != nil {
+			if artifact, ok := info.Artifacts[version]; ok {
+				return version, artifact, nil
+			}
+		}
+	}
+	return version, ArtifactInfo{}, nil
+}
+
+// versionFromArchiveName extracts the version from names like ltth_1.2.0.zip
+func versionFromArchiveName(path string) string {
+	name := filepath.Base(path)
+	name = strings.TrimSuffix(name, archiveExtensions[mediaTypeFromName(name)])
+	name = strings.TrimSuffix(name, ".tgz")
+	name = strings.TrimSuffix(name, ".tzst")
+	return strings.TrimPrefix(strings.TrimPrefix(name, "ltth_"), "v")
+}
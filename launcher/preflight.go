@@ -0,0 +1,177 @@
+/**
+ * LTTH Launcher - Install Preflight
+ *
+ * Before anything is downloaded the launcher checks that the install and
+ * config folders are writable, that their drives have room for the archive,
+ * the unpacked version and a safety margin, and that the version folder
+ * stays within the path length limit. Problems are returned as a list of
+ * issues the UI shows in the user's language.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Preflight issue codes, localized by the UI as preflight.<code>
+const (
+	issueNotWritable = "notWritable"
+	issueDiskSpace   = "diskSpace"
+	issuePathTooLong = "pathTooLong"
+)
+
+const (
+	// unpackFactor estimates the unpacked size of archives without unpackedSize
+	unpackFactor = 3
+	// spaceMargin is kept free on top of the estimated need
+	spaceMargin = 100 << 20
+	// defaultRelativePathLength is assumed for releases without maxPathLength
+	defaultRelativePathLength = 150
+)
+
+// PreflightIssue is one problem that prevents an install
+type PreflightIssue struct {
+	Code      string `json:"code"`
+	Path      string `json:"path"`
+	Required  int64  `json:"required,omitempty"`
+	Available int64  `json:"available,omitempty"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+// preflightInstall checks whether version can be installed with the current paths
+func preflightInstall(info *VersionInfo, version string) []PreflightIssue {
+	return preflightArtifact(version, info.Artifacts[version], true)
+}
+
+// preflightArtifact checks whether artifact can be installed as version; the
+// archive itself only needs room if it still has to be downloaded
+func preflightArtifact(version string, artifact ArtifactInfo, download bool) []PreflightIssue {
+	var issues []PreflightIssue
+	cfg := currentConfig()
+
+	for _, dir := range []string{cfg.InstallPath, cfg.ConfigPath} {
+		if err := checkWritable(dir); err != nil {
+			issues = append(issues, PreflightIssue{Code: issueNotWritable, Path: dir, Detail: err.Error()})
+		}
+	}
+
+	// Delta updates usually need less, but the archive fallback needs all of it
+	unpacked := artifact.UnpackedSize
+	if unpacked == 0 {
+		unpacked = artifact.Size * unpackFactor
+	}
+	need := map[string]int64{cfg.InstallPath: unpacked}
+	if download {
+		need[cfg.InstallPath] += artifact.Size
+	}
+	backup, _ := configBackupSize()
+	need[cfg.ConfigPath] += backup
+
+	// Both folders on one drive share its free space
+	byVolume := make(map[string]int64)
+	volumePath := make(map[string]string)
+	for dir, bytes := range need {
+		volume := strings.ToLower(filepath.VolumeName(dir))
+		byVolume[volume] += bytes
+		if _, ok := volumePath[volume]; !ok || dir == cfg.InstallPath {
+			volumePath[volume] = dir
+		}
+	}
+	for volume, bytes := range byVolume {
+		dir := volumePath[volume]
+		required := bytes + bytes/10 + spaceMargin
+		available, err := freeDiskSpace(existingParent(dir))
+		if err != nil {
+			// Unknown free space is not a reason to refuse the install
+			continue
+		}
+		if available < required {
+			issues = append(issues, PreflightIssue{Code: issueDiskSpace, Path: dir, Required: required, Available: available})
+		}
+	}
+
+	// The app runs with the path limits of the system, even if extracting would work
+	relative := artifact.MaxPathLength
+	if relative == 0 {
+		relative = defaultRelativePathLength
+	}
+	versionDir, _ := filepath.Abs(filepath.Join(cfg.InstallPath, version))
+	if length, limit := len(versionDir)+1+relative, maxPathLength(); length > limit {
+		issues = append(issues, PreflightIssue{Code: issuePathTooLong, Path: cfg.InstallPath, Required: int64(length), Available: int64(limit)})
+	}
+
+	return issues
+}
+
+// checkWritable creates dir if needed and proves a file can be written into it
+func checkWritable(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".ltth-preflight-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write([]byte("ok"))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	os.Remove(f.Name())
+	return err
+}
+
+// configBackupSize returns how much backupConfig will copy
+func configBackupSize() (int64, error) {
+	files, err := configBackupFiles(currentConfig().ConfigPath)
+	if err != nil {
+		return 0, err
+	}
+	total := int64(0)
+	for _, entry := range files {
+		if info, err := entry.Info(); err == nil {
+			total += info.Size()
+		}
+	}
+	return total, nil
+}
+
+// existingParent returns path or its nearest existing parent folder
+func existingParent(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// preflightResponse builds the failed IPC result for preflight issues
+func preflightResponse(issues []PreflightIssue) string {
+	messages := make([]string, len(issues))
+	for i, issue := range issues {
+		switch issue.Code {
+		case issueDiskSpace:
+			messages[i] = fmt.Sprintf("not enough space for %s: %d bytes needed, %d available", issue.Path, issue.Required, issue.Available)
+		case issuePathTooLong:
+			messages[i] = fmt.Sprintf("path %s too long: %d of %d characters", issue.Path, issue.Required, issue.Available)
+		default:
+			messages[i] = fmt.Sprintf("%s is not writable: %s", issue.Path, issue.Detail)
+		}
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"success":   false,
+		"error":     strings.Join(messages, "; "),
+		"errorCode": "preflight",
+		"issues":    issues,
+	})
+	return string(data)
+}
@@ -0,0 +1,32 @@
+//go:build unix
+
+/**
+ * LTTH Launcher - Process Priority and Instances (Unix)
+ */
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// claimInstance reports true; other platforms don't detect a second launcher
+func claimInstance() bool {
+	return true
+}
+
+// enterBackgroundMode lowers the CPU priority of the process
+func enterBackgroundMode() {
+	syscall.Setpriority(syscall.PRIO_PROCESS, 0, 10)
+}
+
+// tryLockFile takes an exclusive lock on f without waiting; false if another
+// process holds it
+func tryLockFile(f *os.File) (bool, error) {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err == syscall.EWOULDBLOCK {
+		return false, nil
+	}
+	return err == nil, err
+}
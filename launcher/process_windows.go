@@ -0,0 +1,40 @@
+/**
+ * LTTH Launcher - Process Priority and Instances (Windows)
+ */
+
+package main
+
+import (
+	"log"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// claimInstance reports whether no other launcher is running; the named
+// mutex is held until the process exits
+func claimInstance() bool {
+	name, err := windows.UTF16PtrFromString(`Local\LTTHLauncher`)
+	if err != nil {
+		return true
+	}
+	_, err = windows.CreateMutex(nil, false, name)
+	return err != windows.ERROR_ALREADY_EXISTS
+}
+
+// enterBackgroundMode lowers CPU, disk and memory priority of the process
+func enterBackgroundMode() {
+	if err := windows.SetPriorityClass(windows.CurrentProcess(), windows.PROCESS_MODE_BACKGROUND_BEGIN); err != nil {
+		log.Printf("Background priority not available: %v", err)
+	}
+}
+
+// tryLockFile takes an exclusive lock on f without waiting; false if another
+// process holds it
+func tryLockFile(f *os.File) (bool, error) {
+	err := windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, &windows.Overlapped{})
+	if err == windows.ERROR_LOCK_VIOLATION {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,178 @@
+/**
+ * LTTH Launcher - Progress Reporting
+ *
+ * Long running steps (download, extraction, backup) report their progress
+ * through a ProgressFunc. Events for the UI are queued on a channel and
+ * pushed into the WebView by a single pump goroutine, so the install can run
+ * off the UI thread without blocking on rendering.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/jchv/go-webview2"
+)
+
+// Progress phases
+const (
+	PhaseDownload = "download"
+	PhaseVerify   = "verify"
+	PhaseBackup   = "backup"
+	PhaseExtract  = "extract"
+	PhaseCheck    = "check"
+)
+
+// Progress units
+const (
+	UnitBytes = "bytes"
+	UnitFiles = "files"
+)
+
+// progressInterval limits how often intermediate events are emitted
+const progressInterval = 100 * time.Millisecond
+
+// ProgressEvent describes the state of a running step
+type ProgressEvent struct {
+	Phase   string  `json:"phase"`
+	Unit    string  `json:"unit"`
+	Done    int64   `json:"done"`
+	Total   int64   `json:"total"`
+	Percent float64 `json:"percent"`
+	Speed   float64 `json:"speed"`
+	ETA     float64 `json:"eta"`
+}
+
+// ProgressFunc receives progress events; a nil ProgressFunc discards them
+type ProgressFunc func(ProgressEvent)
+
+// progressTracker accumulates progress of one phase and derives speed and ETA
+type progressTracker struct {
+	mu       sync.Mutex
+	event    ProgressEvent
+	base     int64
+	started  time.Time
+	lastSent time.Time
+	report   ProgressFunc
+}
+
+// newProgressTracker starts tracking a phase; total is -1 if unknown
+func newProgressTracker(phase, unit string, total int64, report ProgressFunc) *progressTracker {
+	return &progressTracker{
+		event:   ProgressEvent{Phase: phase, Unit: unit, Total: total},
+		started: time.Now(),
+		report:  report,
+	}
+}
+
+// Reset restarts the measurement at done (e.g. when a download resumes)
+func (p *progressTracker) Reset(done, total int64) {
+	p.mu.Lock()
+	p.event.Done = done
+	p.event.Total = total
+	p.base = done
+	p.started = time.Now()
+	p.mu.Unlock()
+	p.emit(true)
+}
+
+// Add records n more units
+func (p *progressTracker) Add(n int64) {
+	p.mu.Lock()
+	p.event.Done += n
+	p.mu.Unlock()
+	p.emit(false)
+}
+
+// Write lets the tracker count bytes passing through an io.Writer chain
+func (p *progressTracker) Write(b []byte) (int, error) {
+	p.Add(int64(len(b)))
+	return len(b), nil
+}
+
+// Finish emits the final event of the phase
+func (p *progressTracker) Finish() {
+	p.mu.Lock()
+	if p.event.Total < 0 {
+		p.event.Total = p.event.Done
+	}
+	p.mu.Unlock()
+	p.emit(true)
+}
+
+// emit sends the current state, throttled unless force is set
+func (p *progressTracker) emit(force bool) {
+	if p.report == nil {
+		return
+	}
+
+	p.mu.Lock()
+	now := time.Now()
+	if !force && now.Sub(p.lastSent) < progressInterval {
+		p.mu.Unlock()
+		return
+	}
+	p.lastSent = now
+
+	event := p.event
+	if elapsed := now.Sub(p.started).Seconds(); elapsed > 0 {
+		event.Speed = float64(event.Done-p.base) / elapsed
+	}
+	if event.Total > 0 {
+		event.Percent = float64(event.Done) * 100 / float64(event.Total)
+		if event.Speed > 0 {
+			event.ETA = float64(event.Total-event.Done) / event.Speed
+		}
+	}
+	p.mu.Unlock()
+
+	p.report(event)
+}
+
+// progressQueue buffers UI events between the installer and the WebView
+var progressQueue = make(chan ProgressEvent, 64)
+
+// uiProgress queues events for the UI; intermediate events are dropped when
+// the UI falls behind, final events of a phase are always delivered
+func uiProgress(event ProgressEvent) {
+	if event.Total >= 0 && event.Done >= event.Total {
+		progressQueue <- event
+		return
+	}
+	select {
+	case progressQueue <- event:
+	default:
+	}
+}
+
+// startProgressPump forwards queued events to window.onLauncherProgress
+func startProgressPump(w webview2.WebView) {
+	go func() {
+		for event := range progressQueue {
+			data, _ := json.Marshal(event)
+			js := "window.onLauncherProgress && window.onLauncherProgress(" + string(data) + ")"
+			w.Dispatch(func() {
+				w.Eval(js)
+			})
+		}
+	}()
+}
+
+// dispatchResult delivers the result of an asynchronous operation to a JS callback
+func dispatchResult(w webview2.WebView, callback, result string) {
+	js := resultScript(callback, result)
+	w.Dispatch(func() {
+		w.Eval(js)
+	})
+}
+
+// resultScript returns the JS calling callback with the JSON result. The
+// result is passed as a quoted string and parsed in the page, so no content
+// of it can end up as code.
+func resultScript(callback, result string) string {
+	quoted, _ := json.Marshal(result)
+	return "window." + callback + " && window." + callback + "(JSON.parse(" + string(quoted) + "))"
+}
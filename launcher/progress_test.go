@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestResultScriptQuotesErrors(t *testing.T) {
+	cause := &url.Error{Op: "Get", URL: "https://ltth.app/app/ltth_1.2.0.zip", Err: errors.New("read: connection reset")}
+	message := "Download failed: " + cause.Error() + "\nC:\\Users\\LTTH\\</script> 'done'\u2028"
+	results := []string{
+		failureResponse(message),
+		errorResponse("checksum", errors.New(message)),
+		versionResponse(`1.2.0"); alert("x`),
+	}
+
+	const prefix = "window.onInstallResult && window.onInstallResult(JSON.parse("
+	for _, result := range results {
+		js := resultScript("onInstallResult", result)
+		if !strings.HasPrefix(js, prefix) || !strings.HasSuffix(js, "))") {
+			t.Fatalf("unexpected script: %s", js)
+		}
+		if strings.ContainsAny(js, "\n\r\u2028\u2029") {
+			t.Errorf("script contains a raw line terminator: %q", js)
+		}
+
+		// The argument of JSON.parse must be a string literal holding the result
+		var literal string
+		if err := json.Unmarshal([]byte(strings.TrimSuffix(strings.TrimPrefix(js, prefix), "))")), &literal); err != nil {
+			t.Fatalf("JSON.parse argument is not a string literal: %v", err)
+		}
+		if literal != result {
+			t.Errorf("script passes %q, want %q", literal, result)
+		}
+		var parsed map[string]interface{}
+		if err := json.Unmarshal([]byte(literal), &parsed); err != nil {
+			t.Errorf("result is not valid JSON: %v", err)
+		}
+	}
+
+	var parsed struct{ Error string }
+	json.Unmarshal([]byte(results[0]), &parsed)
+	if parsed.Error != message {
+		t.Errorf("error = %q, want %q", parsed.Error, message)
+	}
+}
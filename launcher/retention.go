@@ -0,0 +1,257 @@
+/**
+ * LTTH Launcher - Retention of Installed Versions
+ *
+ * Decides which installed versions are kept: the active one, pinned ones,
+ * the ones offered for rollback, the newest keepVersions and everything
+ * installed within keepDays. The rest can be removed from the UI
+ * ("Speicher freigeben") or headlessly with "launcher.exe --cleanup
+ * [--dry-run]".
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ltth-launcher/semver"
+)
+
+// Reasons a version is kept
+const (
+	keepActive   = "active"
+	keepPinned   = "pinned"
+	keepRollback = "rollback"
+	keepNewest   = "newest"
+	keepRecent   = "recent"
+)
+
+// CleanupEntry is one installed version in a cleanup plan
+type CleanupEntry struct {
+	Version     string    `json:"version"`
+	InstalledAt time.Time `json:"installedAt"`
+	Reclaimable int64     `json:"reclaimable"`
+	Reason      string    `json:"reason,omitempty"`
+}
+
+// CleanupPlan lists which versions the retention policy keeps and removes
+type CleanupPlan struct {
+	Keep      []CleanupEntry `json:"keep"`
+	Remove    []CleanupEntry `json:"remove"`
+	Reclaimed int64          `json:"reclaimed"`
+}
+
+// installedVersions returns all completely installed versions, newest first
+func installedVersions() []string {
+	installPath := currentConfig().InstallPath
+	if installPath == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(installPath)
+	if err != nil {
+		return nil
+	}
+
+	var versions []string
+	for _, entry := range entries {
+		// Skip staging/temp folders and versions whose install never completed
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") &&
+			isInstallComplete(filepath.Join(installPath, entry.Name())) {
+			versions = append(versions, entry.Name())
+		}
+	}
+	semver.SortStrings(versions)
+	return versions
+}
+
+// installedAt returns when a version was installed, falling back to the folder time
+func installedAt(version string) time.Time {
+	dir := filepath.Join(currentConfig().InstallPath, version)
+	if data, err := os.ReadFile(filepath.Join(dir, installMarkerName)); err == nil {
+		var marker InstallMarker
+		if json.Unmarshal(data, &marker) == nil && !marker.InstalledAt.IsZero() {
+			return marker.InstalledAt
+		}
+	}
+	if info, err := os.Stat(dir); err == nil {
+		return info.ModTime()
+	}
+	return time.Time{}
+}
+
+// isPinned reports whether the user pinned a version
+func isPinned(version string) bool {
+	return containsVersion(currentConfig().PinnedVersions, version)
+}
+
+// containsVersion reports whether versions lists version
+func containsVersion(versions []string, version string) bool {
+	for _, v := range versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// planCleanup applies the retention policy to the installed versions
+func planCleanup() CleanupPlan {
+	plan := CleanupPlan{Keep: []CleanupEntry{}, Remove: []CleanupEntry{}}
+	cfg := currentConfig()
+	cutoff := time.Now().AddDate(0, 0, -cfg.KeepDays)
+
+	// installedVersions is sorted newest first, so the first keepVersions are the newest
+	for i, version := range installedVersions() {
+		entry := CleanupEntry{Version: version, InstalledAt: installedAt(version)}
+		switch {
+		case version == cfg.LastVersion:
+			entry.Reason = keepActive
+		case isPinned(version):
+			entry.Reason = keepPinned
+		case containsVersion(cfg.PreviousVersions, version):
+			entry.Reason = keepRollback
+		case i < cfg.KeepVersions:
+			entry.Reason = keepNewest
+		case cfg.KeepDays > 0 && entry.InstalledAt.After(cutoff):
+			entry.Reason = keepRecent
+		}
+
+		if entry.Reason != "" {
+			plan.Keep = append(plan.Keep, entry)
+		} else {
+			plan.Remove = append(plan.Remove, entry)
+		}
+	}
+
+	plan.Reclaimed = estimateReclaimable(&plan)
+	return plan
+}
+
+// estimateReclaimable fills in how much space removing each version frees.
+// Files shared through the store only count if no kept version uses them.
+func estimateReclaimable(plan *CleanupPlan) int64 {
+	installPath := currentConfig().InstallPath
+	keptContent := make(map[string]bool)
+	for _, entry := range plan.Keep {
+		if manifest, err := loadInstallManifest(filepath.Join(installPath, entry.Version)); err == nil {
+			for _, file := range manifest.Files {
+				keptContent[strings.ToLower(file.SHA256)] = true
+			}
+		}
+	}
+
+	total := int64(0)
+	counted := make(map[string]bool)
+	for i := range plan.Remove {
+		dir := filepath.Join(installPath, plan.Remove[i].Version)
+		sums := make(map[string]string)
+		if manifest, err := loadInstallManifest(dir); err == nil {
+			for _, file := range manifest.Files {
+				sums[file.Path] = strings.ToLower(file.SHA256)
+			}
+		}
+
+		size := int64(0)
+		paths, _ := listInstalledFiles(dir)
+		for _, rel := range paths {
+			info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(rel)))
+			if err != nil {
+				continue
+			}
+			sum := sums[rel]
+			if len(sum) == 64 {
+				if blob, err := os.Stat(blobPath(sum)); err == nil && os.SameFile(info, blob) {
+					// Stored once: freed only with the last version that links it
+					if keptContent[sum] || counted[sum] {
+						continue
+					}
+					counted[sum] = true
+				}
+			}
+			size += info.Size()
+		}
+		plan.Remove[i].Reclaimable = size
+		total += size
+	}
+	return total
+}
+
+// applyCleanup removes the versions of a plan and returns the removed ones
+func applyCleanup(plan CleanupPlan) ([]string, error) {
+	var removed []string
+	for _, entry := range plan.Remove {
+		if err := removeVersion(entry.Version); err != nil {
+			return removed, fmt.Errorf("could not remove %s: %v", entry.Version, err)
+		}
+		removed = append(removed, entry.Version)
+		log.Printf("Removed version %s (%d bytes)", entry.Version, entry.Reclaimable)
+	}
+
+	if len(removed) > 0 {
+		// Removed versions can no longer be rolled back to
+		installed := make(map[string]bool)
+		for _, v := range installedVersions() {
+			installed[v] = true
+		}
+		updateConfig(func(c *LauncherConfig) {
+			kept := []string{}
+			for _, v := range c.PreviousVersions {
+				if installed[v] {
+					kept = append(kept, v)
+				}
+			}
+			c.PreviousVersions = kept
+		})
+	}
+
+	if err := collectStoreGarbage(); err != nil {
+		log.Printf("Shared store cleanup skipped: %v", err)
+	}
+	return removed, nil
+}
+
+// removeVersion deletes a version folder. It is moved into staging first so
+// a partly deleted folder is never mistaken for an installed version.
+func removeVersion(version string) error {
+	cfg := currentConfig()
+	if !isValidVersionName(version) || version == cfg.LastVersion {
+		return fmt.Errorf("version %s must not be removed", version)
+	}
+	dir, err := newStagingDir(version)
+	if err != nil {
+		return err
+	}
+	os.Remove(dir)
+	if err := os.Rename(filepath.Join(cfg.InstallPath, version), dir); err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// runHeadlessCleanup implements "--cleanup [--dry-run]" and prints the plan to stdout
+func runHeadlessCleanup(dryRun bool) error {
+	if currentConfig().InstallPath == "" {
+		return fmt.Errorf("no install path configured")
+	}
+
+	plan := planCleanup()
+	for _, entry := range plan.Keep {
+		fmt.Printf("keep    %-24s %s\n", entry.Version, entry.Reason)
+	}
+	for _, entry := range plan.Remove {
+		fmt.Printf("remove  %-24s %.1f MB\n", entry.Version, float64(entry.Reclaimable)/1024/1024)
+	}
+	fmt.Printf("%d versions to remove, %.1f MB reclaimable\n", len(plan.Remove), float64(plan.Reclaimed)/1024/1024)
+
+	if dryRun || len(plan.Remove) == 0 {
+		return nil
+	}
+	removed, err := applyCleanup(plan)
+	fmt.Printf("Removed %d versions\n", len(removed))
+	return err
+}
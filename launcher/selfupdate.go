@@ -0,0 +1,243 @@
+/**
+ * LTTH Launcher - Self-Update
+ *
+ * version.json announces the newest launcher in its "launcher" section. The
+ * new executable is downloaded and verified next to the running one as
+ * <exe>.new and swapped in on the next start. The replaced binary is kept
+ * as <exe>.old; "launcher.exe --restore-launcher" switches back to it.
+ *
+ * Windows allows renaming a running executable but not overwriting it, so
+ * the swap is two renames followed by a restart.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Files next to the launcher executable
+const (
+	launcherNewSuffix      = ".new"
+	launcherNewMetaSuffix  = ".new.json"
+	launcherOldSuffix      = ".old"
+	launcherDownloadSuffix = ".download"
+)
+
+// LauncherRelease describes the newest launcher build in version.json
+type LauncherRelease struct {
+	Version string `json:"version"`
+	URL     string `json:"url"`
+	SHA256  string `json:"sha256"`
+	Size    int64  `json:"size"`
+}
+
+// stagedLauncher is written next to a verified <exe>.new
+type stagedLauncher struct {
+	Version string `json:"version"`
+	SHA256  string `json:"sha256"`
+}
+
+// launcherExecutable returns the resolved path of the running launcher
+func launcherExecutable() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	return exe, nil
+}
+
+// launcherUpdateAvailable reports whether info announces a newer launcher than this one
+func launcherUpdateAvailable(info *VersionInfo) bool {
+	return info != nil && info.Launcher != nil && info.Launcher.Version != "" &&
+		compareVersions(info.Launcher.Version, AppVersion) > 0
+}
+
+// loadStagedLauncher returns the launcher update waiting for a restart, if any
+func loadStagedLauncher(exe string) (stagedLauncher, bool) {
+	var staged stagedLauncher
+	data, err := os.ReadFile(exe + launcherNewMetaSuffix)
+	if err != nil || json.Unmarshal(data, &staged) != nil {
+		return staged, false
+	}
+	if _, err := os.Stat(exe + launcherNewSuffix); err != nil {
+		return staged, false
+	}
+	return staged, true
+}
+
+// discardStagedLauncher removes a staged update that must not be applied
+func discardStagedLauncher(exe string) {
+	os.Remove(exe + launcherNewSuffix)
+	os.Remove(exe + launcherNewMetaSuffix)
+}
+
+// applyPendingLauncherUpdate swaps in a staged launcher and starts it.
+// It returns true if the new launcher was started and this process should exit.
+func applyPendingLauncherUpdate() bool {
+	exe, err := launcherExecutable()
+	if err != nil {
+		log.Printf("Launcher update: %v", err)
+		return false
+	}
+	os.Remove(exe + launcherDownloadSuffix)
+
+	staged, ok := loadStagedLauncher(exe)
+	if !ok {
+		return false
+	}
+	if compareVersions(staged.Version, AppVersion) <= 0 {
+		log.Printf("Discarding staged launcher %s (running %s)", staged.Version, AppVersion)
+		discardStagedLauncher(exe)
+		return false
+	}
+
+	// The staged file was verified when it was downloaded; make sure it wasn't touched since
+	if err := verifyDigest(exe+launcherNewSuffix, 0, staged.SHA256); err != nil {
+		log.Printf("Discarding staged launcher %s: %v", staged.Version, err)
+		discardStagedLauncher(exe)
+		return false
+	}
+
+	old := exe + launcherOldSuffix
+	os.Remove(old)
+	if err := os.Rename(exe, old); err != nil {
+		log.Printf("Launcher update: could not move current launcher aside: %v", err)
+		return false
+	}
+	if err := os.Rename(exe+launcherNewSuffix, exe); err != nil {
+		log.Printf("Launcher update: could not install new launcher: %v", err)
+		os.Rename(old, exe)
+		return false
+	}
+	os.Remove(exe + launcherNewMetaSuffix)
+
+	if err := startLauncher(exe); err != nil {
+		log.Printf("Launcher update: new launcher failed to start, restoring %s: %v", AppVersion, err)
+		os.Remove(exe)
+		os.Rename(old, exe)
+		return false
+	}
+
+	log.Printf("Launcher updated from %s to %s", AppVersion, staged.Version)
+	return true
+}
+
+// restorePreviousLauncher swaps the running launcher with <exe>.old and starts it
+func restorePreviousLauncher() error {
+	exe, err := launcherExecutable()
+	if err != nil {
+		return err
+	}
+	old := exe + launcherOldSuffix
+	if _, err := os.Stat(old); err != nil {
+		return fmt.Errorf("no previous launcher found: %v", err)
+	}
+
+	// Keep the current binary as the new .old so the restore can be undone too
+	swap := exe + ".swap"
+	if err := os.Rename(exe, swap); err != nil {
+		return err
+	}
+	if err := os.Rename(old, exe); err != nil {
+		os.Rename(swap, exe)
+		return err
+	}
+	if err := os.Rename(swap, old); err != nil {
+		log.Printf("Warning: could not keep replaced launcher: %v", err)
+	}
+	discardStagedLauncher(exe)
+
+	log.Printf("Restored previous launcher (replaced %s)", AppVersion)
+	return startLauncher(exe)
+}
+
+// startLauncher starts a new launcher process without the self-update flags
+func startLauncher(exe string) error {
+	var args []string
+	for _, arg := range os.Args[1:] {
+		if arg != "--restore-launcher" && arg != "-restore-launcher" {
+			args = append(args, arg)
+		}
+	}
+	return exec.Command(exe, args...).Start()
+}
+
+// performLauncherUpdate downloads and verifies the announced launcher and stages it for the next start
+func performLauncherUpdate(report ProgressFunc) string {
+	info, err := loadVersionInfo()
+	if err != nil {
+		return failureResponse(err.Error())
+	}
+	if !launcherUpdateAvailable(info) {
+		return `{"success": false, "error": "No launcher update available"}`
+	}
+	release := info.Launcher
+
+	// The launcher replaces itself, so an unchecked download is never accepted
+	if !strings.HasPrefix(release.URL, "https://") || release.SHA256 == "" {
+		return errorResponse("launcherUpdate", fmt.Errorf("launcher %s is published without https URL or checksum", release.Version))
+	}
+
+	exe, err := launcherExecutable()
+	if err != nil {
+		return errorResponse("launcherUpdate", err)
+	}
+	if staged, ok := loadStagedLauncher(exe); ok && staged.Version == release.Version {
+		return launcherStagedResponse(release.Version)
+	}
+
+	log.Printf("Downloading launcher %s from: %s", release.Version, release.URL)
+	download := exe + launcherDownloadSuffix
+	sum, err := downloadFile(download, release.URL, report)
+	if err != nil {
+		return errorResponse("launcherUpdate", fmt.Errorf("download failed: %v", err))
+	}
+
+	if report != nil {
+		report(ProgressEvent{Phase: PhaseVerify, Unit: UnitBytes, Total: -1})
+	}
+	if err := verifyDownload(download, release.Size, sum, release.SHA256); err != nil {
+		log.Printf("Launcher verification failed: %v", err)
+		os.Remove(download)
+		return errorResponse("checksum", err)
+	}
+	if err := verifyArtifactSignature(download, release.URL); err != nil {
+		log.Printf("Launcher signature rejected: %v", err)
+		os.Remove(download)
+		return errorResponse("signature", err)
+	}
+
+	discardStagedLauncher(exe)
+	if err := os.Rename(download, exe+launcherNewSuffix); err != nil {
+		os.Remove(download)
+		return errorResponse("launcherUpdate", err)
+	}
+	meta, _ := json.Marshal(stagedLauncher{Version: release.Version, SHA256: release.SHA256})
+	if err := os.WriteFile(exe+launcherNewMetaSuffix, meta, 0644); err != nil {
+		discardStagedLauncher(exe)
+		return errorResponse("launcherUpdate", err)
+	}
+
+	log.Printf("Launcher %s staged, applied on next start", release.Version)
+	return launcherStagedResponse(release.Version)
+}
+
+// launcherStagedResponse builds the IPC result of a launcher update applied on restart
+func launcherStagedResponse(version string) string {
+	data, _ := json.Marshal(map[string]interface{}{
+		"success":         true,
+		"version":         version,
+		"restartRequired": true,
+	})
+	return string(data)
+}
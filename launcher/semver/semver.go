@@ -0,0 +1,379 @@
+/**
+ * LTTH Launcher - Semantic Versioning
+ *
+ * Parsing and ordering of versions per SemVer 2.0.0 including pre-release
+ * and build metadata, plus range constraints such as ">=1.2 <2".
+ *
+ * Accepts the same version strings as scripts/release_from_new_patch.py
+ * (optional "ltth_" prefix), and an optional leading "v".
+ */
+
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed semantic version
+type Version struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	PreRelease []string
+	Build      string
+}
+
+var versionPattern = regexp.MustCompile(`^(0|[1-9]\d*)(?:\.(0|[1-9]\d*))?(?:\.(0|[1-9]\d*))?(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?$`)
+
+// Parse parses a full "MAJOR.MINOR.PATCH[-PRERELEASE][+BUILD]" version
+func Parse(s string) (Version, error) {
+	v, parts, err := parse(s)
+	if err != nil {
+		return Version{}, err
+	}
+	if parts < 3 {
+		return Version{}, fmt.Errorf("invalid semantic version: %q", s)
+	}
+	return v, nil
+}
+
+// ParseLoose parses a version that may omit minor and patch ("1.2" is 1.2.0)
+func ParseLoose(s string) (Version, error) {
+	v, _, err := parse(s)
+	return v, err
+}
+
+// parse returns the version and how many numeric parts were given
+func parse(s string) (Version, int, error) {
+	trimmed := strings.TrimPrefix(strings.TrimSpace(s), "ltth_")
+	trimmed = strings.TrimPrefix(trimmed, "v")
+
+	m := versionPattern.FindStringSubmatch(trimmed)
+	if m == nil {
+		return Version{}, 0, fmt.Errorf("invalid semantic version: %q", s)
+	}
+
+	var v Version
+	parts := 0
+	for i, dst := range []*uint64{&v.Major, &v.Minor, &v.Patch} {
+		if m[i+1] == "" {
+			continue
+		}
+		n, err := strconv.ParseUint(m[i+1], 10, 64)
+		if err != nil {
+			return Version{}, 0, fmt.Errorf("invalid semantic version: %q", s)
+		}
+		*dst = n
+		parts++
+	}
+
+	if m[4] != "" {
+		v.PreRelease = strings.Split(m[4], ".")
+		for _, id := range v.PreRelease {
+			if len(id) > 1 && id[0] == '0' && isNumeric(id) {
+				return Version{}, 0, fmt.Errorf("invalid semantic version: %q (leading zero in %q)", s, id)
+			}
+		}
+	}
+	v.Build = m[5]
+	return v, parts, nil
+}
+
+// String formats the version in canonical form
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.PreRelease) > 0 {
+		s += "-" + strings.Join(v.PreRelease, ".")
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// IsPreRelease reports whether the version has pre-release identifiers
+func (v Version) IsPreRelease() bool {
+	return len(v.PreRelease) > 0
+}
+
+// Compare returns -1, 0 or 1 following SemVer 2.0 precedence; build metadata is ignored
+func (v Version) Compare(o Version) int {
+	if c := compareUint(v.Major, o.Major); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+
+	// A version without pre-release has higher precedence than one with
+	switch {
+	case len(v.PreRelease) == 0 && len(o.PreRelease) == 0:
+		return 0
+	case len(v.PreRelease) == 0:
+		return 1
+	case len(o.PreRelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.PreRelease) && i < len(o.PreRelease); i++ {
+		if c := compareIdentifier(v.PreRelease[i], o.PreRelease[i]); c != 0 {
+			return c
+		}
+	}
+	return compareUint(uint64(len(v.PreRelease)), uint64(len(o.PreRelease)))
+}
+
+// compareIdentifier compares pre-release identifiers: numeric ones numerically
+// and lower than alphanumeric ones, which compare in ASCII order
+func compareIdentifier(a, b string) int {
+	aNum, bNum := isNumeric(a), isNumeric(b)
+	switch {
+	case aNum && bNum:
+		if len(a) != len(b) {
+			return compareUint(uint64(len(a)), uint64(len(b)))
+		}
+		return strings.Compare(a, b)
+	case aNum:
+		return -1
+	case bNum:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func isNumeric(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// CompareStrings compares two version strings. Unparsable versions sort
+// below all valid ones and are ordered among themselves as plain strings.
+func CompareStrings(a, b string) int {
+	va, errA := ParseLoose(a)
+	vb, errB := ParseLoose(b)
+	switch {
+	case errA == nil && errB == nil:
+		return va.Compare(vb)
+	case errA == nil:
+		return 1
+	case errB == nil:
+		return -1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// SortStrings sorts version strings by precedence, newest first
+func SortStrings(versions []string) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		return CompareStrings(versions[i], versions[j]) > 0
+	})
+}
+
+// Constraint is a set of alternatives ("||"), each a list of comparators that must all match
+type Constraint struct {
+	alternatives [][]comparator
+	text         string
+}
+
+type comparator struct {
+	op      string
+	version Version
+}
+
+// operators are the comparator prefixes, longest first
+var operators = []string{">=", "<=", "!=", ">", "<", "=", "^", "~"}
+
+// ParseConstraint parses expressions like ">=1.2 <2", "^1.2.0", "~1.2" or "1.x || >=2.1.0".
+// A bare version means "=". Operator and version may be separated by spaces (">= 1.2").
+func ParseConstraint(s string) (Constraint, error) {
+	c := Constraint{text: s}
+	for _, alt := range strings.Split(s, "||") {
+		var group []comparator
+		for _, field := range constraintTokens(alt) {
+			comps, err := parseComparator(field)
+			if err != nil {
+				return Constraint{}, fmt.Errorf("invalid constraint %q: %v", s, err)
+			}
+			group = append(group, comps...)
+		}
+		if len(group) == 0 {
+			return Constraint{}, fmt.Errorf("invalid constraint %q: empty expression", s)
+		}
+		c.alternatives = append(c.alternatives, group)
+	}
+	return c, nil
+}
+
+// constraintTokens splits an alternative into comparator tokens, joining an
+// operator standing alone with the version after it
+func constraintTokens(alt string) []string {
+	fields := strings.Fields(alt)
+	var tokens []string
+	for i := 0; i < len(fields); i++ {
+		token := fields[i]
+		if isOperator(token) && i+1 < len(fields) {
+			i++
+			token += fields[i]
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+func isOperator(s string) bool {
+	for _, op := range operators {
+		if s == op {
+			return true
+		}
+	}
+	return false
+}
+
+// parseComparator turns one token into one or more primitive comparators
+func parseComparator(token string) ([]comparator, error) {
+	op := ""
+	for _, prefix := range operators {
+		if strings.HasPrefix(token, prefix) {
+			op = prefix
+			token = strings.TrimSpace(strings.TrimPrefix(token, prefix))
+			break
+		}
+	}
+
+	// Wildcards: "1.x", "1.2.*"
+	for strings.HasSuffix(token, ".x") || strings.HasSuffix(token, ".X") || strings.HasSuffix(token, ".*") {
+		token = token[:len(token)-2]
+	}
+	if token == "*" || token == "x" || token == "X" {
+		return []comparator{{op: ">=", version: Version{}}}, nil
+	}
+
+	v, parts, err := parse(token)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "^":
+		// Allow changes that don't modify the left-most non-zero part
+		upper := Version{Major: v.Major + 1}
+		if v.Major == 0 && parts > 1 {
+			upper = Version{Minor: v.Minor + 1}
+			if v.Minor == 0 && parts > 2 {
+				upper = Version{Patch: v.Patch + 1}
+			}
+		}
+		return []comparator{{">=", v}, {"<", withPre0(upper)}}, nil
+	case "~":
+		// Allow patch changes, or minor changes if only the major was given
+		upper := Version{Major: v.Major, Minor: v.Minor + 1}
+		if parts == 1 {
+			upper = Version{Major: v.Major + 1}
+		}
+		return []comparator{{">=", v}, {"<", withPre0(upper)}}, nil
+	case "", "=":
+		if parts < 3 {
+			// Partial versions match the whole range: "1.2" is >=1.2.0 <1.3.0-0
+			upper := Version{Major: v.Major + 1}
+			if parts == 2 {
+				upper = Version{Major: v.Major, Minor: v.Minor + 1}
+			}
+			return []comparator{{">=", v}, {"<", withPre0(upper)}}, nil
+		}
+		return []comparator{{"=", v}}, nil
+	case "<":
+		if parts < 3 {
+			return []comparator{{"<", withPre0(v)}}, nil
+		}
+	case ">":
+		if parts < 3 {
+			// ">1.2" means above every 1.2.x
+			upper := Version{Major: v.Major + 1}
+			if parts == 2 {
+				upper = Version{Major: v.Major, Minor: v.Minor + 1}
+			}
+			return []comparator{{">=", withPre0(upper)}}, nil
+		}
+	case "<=":
+		if parts < 3 {
+			upper := Version{Major: v.Major + 1}
+			if parts == 2 {
+				upper = Version{Major: v.Major, Minor: v.Minor + 1}
+			}
+			return []comparator{{"<", withPre0(upper)}}, nil
+		}
+	}
+	return []comparator{{op, v}}, nil
+}
+
+// withPre0 returns the lowest possible version of a release line (x.y.z-0),
+// so an upper bound like "<2" also excludes 2.0.0 pre-releases
+func withPre0(v Version) Version {
+	v.PreRelease = []string{"0"}
+	return v
+}
+
+// Check reports whether v satisfies the constraint
+func (c Constraint) Check(v Version) bool {
+	for _, group := range c.alternatives {
+		ok := true
+		for _, comp := range group {
+			if !comp.matches(v) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the constraint as written
+func (c Constraint) String() string {
+	return c.text
+}
+
+func (c comparator) matches(v Version) bool {
+	cmp := v.Compare(c.version)
+	switch c.op {
+	case "=", "":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
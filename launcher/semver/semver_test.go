@@ -0,0 +1,174 @@
+package semver
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"1.2.3", "1.2.3", true},
+		{"v1.2.3", "1.2.3", true},
+		{"ltth_1.2.3", "1.2.3", true},
+		{" 1.2.3 ", "1.2.3", true},
+		{"1.0.0-beta.11+build.5", "1.0.0-beta.11+build.5", true},
+		{"1.0.0-0", "1.0.0-0", true},
+		{"1.2", "", false},
+		{"01.2.3", "", false},
+		{"1.2.3-01", "", false},
+		{"1.2.3-", "", false},
+		{"1.2.3+", "", false},
+		{"1.2.3.4", "", false},
+		{"latest", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		v, err := Parse(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("Parse(%q) error = %v, want ok = %v", tt.in, err, tt.ok)
+			continue
+		}
+		if tt.ok && v.String() != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.in, v, tt.want)
+		}
+	}
+}
+
+func TestParseLoose(t *testing.T) {
+	for in, want := range map[string]string{"1": "1.0.0", "1.2": "1.2.0", "v2.1-rc.1": "2.1.0-rc.1"} {
+		v, err := ParseLoose(in)
+		if err != nil || v.String() != want {
+			t.Errorf("ParseLoose(%q) = %s, %v; want %s", in, v, err, want)
+		}
+	}
+}
+
+func TestComparePrecedence(t *testing.T) {
+	// Ascending order from the SemVer 2.0.0 specification
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.1.0",
+		"2.0.0",
+		"10.0.0",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			a, b := mustParse(t, ordered[i]), mustParse(t, ordered[j])
+			want := compareUint(uint64(i), uint64(j))
+			if got := a.Compare(b); got != want {
+				t.Errorf("%s.Compare(%s) = %d, want %d", a, b, got, want)
+			}
+		}
+	}
+}
+
+func TestCompareIgnoresBuild(t *testing.T) {
+	if c := mustParse(t, "1.2.3+a").Compare(mustParse(t, "1.2.3+b")); c != 0 {
+		t.Errorf("Compare with different build metadata = %d, want 0", c)
+	}
+}
+
+func TestIsPreRelease(t *testing.T) {
+	if !mustParse(t, "1.2.3-beta").IsPreRelease() || mustParse(t, "1.2.3+build").IsPreRelease() {
+		t.Error("IsPreRelease doesn't follow the pre-release identifiers")
+	}
+}
+
+func TestCompareStrings(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.10.0", "1.9.0", 1},
+		{"1.2", "1.2.0", 0},
+		{"v1.2.3", "ltth_1.2.3", 0},
+		{"1.0.0", "latest", 1},
+		{"custom-a", "custom-b", -1},
+	}
+	for _, tt := range tests {
+		if got := CompareStrings(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareStrings(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSortStrings(t *testing.T) {
+	versions := []string{"1.2.0", "broken", "1.10.0", "1.10.0-rc.1", "1.9.9"}
+	SortStrings(versions)
+	want := []string{"1.10.0", "1.10.0-rc.1", "1.9.9", "1.2.0", "broken"}
+	if !reflect.DeepEqual(versions, want) {
+		t.Errorf("SortStrings() = %v, want %v", versions, want)
+	}
+}
+
+func TestConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		matches    []string
+		rejects    []string
+	}{
+		{">=1.2 <2", []string{"1.2.0", "1.9.9", "1.5.0-beta"}, []string{"1.1.9", "2.0.0", "2.0.0-rc.1"}},
+		{">= 1.2 < 2", []string{"1.2.0", "1.9.9"}, []string{"1.1.9", "2.0.0"}},
+		{">=  1.2.0   <=1.4", []string{"1.2.0", "1.4.7"}, []string{"1.5.0"}},
+		{"^1.2.0", []string{"1.2.0", "1.9.0"}, []string{"1.1.0", "2.0.0", "2.0.0-alpha"}},
+		{"^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.3.0"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"~1.2", []string{"1.2.0", "1.2.9"}, []string{"1.3.0"}},
+		{"~ 1", []string{"1.0.0", "1.9.0"}, []string{"2.0.0"}},
+		{"1.x || >=2.1.0", []string{"1.0.0", "1.99.0", "2.1.0", "3.0.0"}, []string{"2.0.5", "0.9.0"}},
+		{"1.2.*", []string{"1.2.0", "1.2.5"}, []string{"1.3.0"}},
+		{"*", []string{"0.0.1", "5.0.0"}, nil},
+		{"1.2", []string{"1.2.0", "1.2.4"}, []string{"1.3.0", "1.1.0"}},
+		{"=1.2.3", []string{"1.2.3", "1.2.3+build"}, []string{"1.2.4", "1.2.3-rc.1"}},
+		{"!= 1.2.3", []string{"1.2.4"}, []string{"1.2.3"}},
+		{">1.2", []string{"1.3.0", "1.3.0-0"}, []string{"1.2.9"}},
+		{"<1.2", []string{"1.1.9"}, []string{"1.2.0-rc.1", "1.2.0"}},
+		{">=1.0.0-beta.2", []string{"1.0.0-beta.11", "1.0.0"}, []string{"1.0.0-beta.1", "1.0.0-alpha"}},
+	}
+	for _, tt := range tests {
+		c, err := ParseConstraint(tt.constraint)
+		if err != nil {
+			t.Errorf("ParseConstraint(%q) error: %v", tt.constraint, err)
+			continue
+		}
+		for _, v := range tt.matches {
+			if !c.Check(mustParse(t, v)) {
+				t.Errorf("%q should match %s", tt.constraint, v)
+			}
+		}
+		for _, v := range tt.rejects {
+			if c.Check(mustParse(t, v)) {
+				t.Errorf("%q should not match %s", tt.constraint, v)
+			}
+		}
+	}
+}
+
+func TestParseConstraintErrors(t *testing.T) {
+	for _, s := range []string{"", "   ", ">=", ">= 1.2 ||", ">=1.2 <", "1.2.3.4", ">=abc", "=> 1.2"} {
+		if _, err := ParseConstraint(s); err == nil {
+			t.Errorf("ParseConstraint(%q) accepted an invalid constraint", s)
+		}
+	}
+}
+
+func mustParse(t *testing.T, s string) Version {
+	t.Helper()
+	v, err := Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
@@ -0,0 +1,190 @@
+/**
+ * LTTH Launcher - Release Signature Verification
+ *
+ * Trusted keys are embedded from keys/trusted.txt, revoked key IDs from
+ * keys/revoked.txt. Releases are signed with cmd/ltth-sign. A build without
+ * keys refuses every update unless it was built with
+ * -ldflags "-X main.allowUnsigned=1" for development.
+ */
+
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	_ "embed"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+
+	"ltth-launcher/signing"
+)
+
+//go:embed keys/trusted.txt
+var trustedKeysFile []byte
+
+//go:embed keys/revoked.txt
+var revokedKeysFile []byte
+
+// allowUnsigned is set to "1" at build time for development builds that
+// install unsigned releases
+var allowUnsigned string
+
+// releaseKeyRing builds the key ring from the embedded key lists and the
+// revocations received with earlier signed manifests
+func releaseKeyRing() *signing.KeyRing {
+	ring := signing.NewKeyRing(embeddedKeys(), signing.ParseIDList(revokedKeysFile))
+	ring.Revoke(currentConfig().RevokedKeys...)
+	return ring
+}
+
+// embeddedKeys returns the public keys compiled into this build
+func embeddedKeys() []ed25519.PublicKey {
+	keys, err := signing.ParseKeyList(trustedKeysFile)
+	if err != nil {
+		log.Printf("Error parsing embedded signing keys: %v", err)
+	}
+	return keys
+}
+
+// signatureRequired reports whether this build enforces release signatures.
+// Only development builds without embedded keys that explicitly allow it skip
+// verification; any other build without usable keys refuses every update.
+func signatureRequired() bool {
+	if len(signing.ParseIDList(trustedKeysFile)) > 0 || allowUnsigned != "1" {
+		return true
+	}
+	log.Println("Warning: development build without signing keys, signature verification disabled")
+	return false
+}
+
+// fetchSignature downloads the detached signature for url
+func fetchSignature(url string) ([]byte, error) {
+	resp, err := httpClient().Get(url + signing.SignatureExt)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("signature not available: %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+}
+
+// verifyManifestSignature verifies raw manifest bytes (version.json, file lists) downloaded from url
+func verifyManifestSignature(data []byte, url string) error {
+	_, err := fetchManifestSignature(data, url)
+	return err
+}
+
+// fetchManifestSignature verifies manifest bytes downloaded from url and
+// returns the signature, so it can be cached with them; nil if not enforced
+func fetchManifestSignature(data []byte, url string) ([]byte, error) {
+	if !signatureRequired() {
+		return nil, nil
+	}
+	sig, err := fetchSignature(url)
+	if err != nil {
+		return nil, fmt.Errorf("%s signature: %v", path.Base(url), err)
+	}
+	if err := releaseKeyRing().Verify(bytes.NewReader(data), sig); err != nil {
+		return nil, fmt.Errorf("%s signature: %v", path.Base(url), err)
+	}
+	return sig, nil
+}
+
+// verifyCachedManifest verifies cached manifest bytes against their cached signature
+func verifyCachedManifest(data, sig []byte, name string) error {
+	if !signatureRequired() {
+		return nil
+	}
+	if len(sig) == 0 {
+		return fmt.Errorf("%s signature: not cached", name)
+	}
+	if err := releaseKeyRing().Verify(bytes.NewReader(data), sig); err != nil {
+		return fmt.Errorf("%s signature: %v", name, err)
+	}
+	return nil
+}
+
+// verifyArtifactSignature verifies a downloaded archive against the signature published next to url
+func verifyArtifactSignature(path, url string) error {
+	if !signatureRequired() {
+		return nil
+	}
+	sig, err := fetchSignature(url)
+	if err != nil {
+		return fmt.Errorf("archive signature: %v", err)
+	}
+	if err := releaseKeyRing().VerifyFile(path, sig); err != nil {
+		return fmt.Errorf("archive signature: %v", err)
+	}
+	return nil
+}
+
+// readLocalSignature reads the detached signature next to a local file
+func readLocalSignature(path string) ([]byte, error) {
+	f, err := os.Open(path + signing.SignatureExt)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, 64*1024))
+}
+
+// verifyLocalManifestSignature verifies manifest bytes read from a local file
+func verifyLocalManifestSignature(data []byte, path string) error {
+	if !signatureRequired() {
+		return nil
+	}
+	sig, err := readLocalSignature(path)
+	if err != nil {
+		return fmt.Errorf("%s signature: %v", filepath.Base(path), err)
+	}
+	if err := releaseKeyRing().Verify(bytes.NewReader(data), sig); err != nil {
+		return fmt.Errorf("%s signature: %v", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// verifyLocalSignature verifies a local archive against the signature next to it
+func verifyLocalSignature(path string) error {
+	if !signatureRequired() {
+		return nil
+	}
+	sig, err := readLocalSignature(path)
+	if err != nil {
+		return fmt.Errorf("archive signature: %v", err)
+	}
+	if err := releaseKeyRing().VerifyFile(path, sig); err != nil {
+		return fmt.Errorf("archive signature: %v", err)
+	}
+	return nil
+}
+
+// applyRevocations persists key revocations announced by a verified manifest
+func applyRevocations(ids []string) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	known := make(map[string]bool)
+	for _, id := range config.RevokedKeys {
+		known[id] = true
+	}
+	changed := false
+	for _, id := range ids {
+		if !known[id] {
+			config.RevokedKeys = append(config.RevokedKeys, id)
+			known[id] = true
+			changed = true
+			log.Printf("Signing key %s revoked by manifest", id)
+		}
+	}
+	if changed {
+		saveConfig()
+	}
+}
@@ -0,0 +1,204 @@
+/**
+ * LTTH Launcher - Release Signatures
+ *
+ * Detached Ed25519 signatures for version.json and release archives.
+ * Files are signed as Ed25519ph (SHA-512 prehash) so large archives can be
+ * verified without loading them into memory.
+ */
+
+package signing
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Algorithm is the only signature algorithm accepted by the launcher
+const Algorithm = "ed25519ph"
+
+// SignatureExt is appended to a file name to get its detached signature
+const SignatureExt = ".sig"
+
+// ErrNoTrustedKeys is returned when the key ring has no usable keys
+var ErrNoTrustedKeys = errors.New("no trusted signing keys configured")
+
+// Signature is a single signature made by one release key
+type Signature struct {
+	KeyID     string `json:"keyId"`
+	Algorithm string `json:"algorithm"`
+	Signature string `json:"signature"`
+}
+
+// SignatureFile is the content of a detached .sig file. During key rotation
+// a file carries signatures of both the old and the new key.
+type SignatureFile struct {
+	Signatures []Signature `json:"signatures"`
+}
+
+// KeyRing holds trusted public keys and revoked key IDs
+type KeyRing struct {
+	keys    map[string]ed25519.PublicKey
+	revoked map[string]bool
+}
+
+// KeyID derives the short identifier of a public key
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// ParseKeyList parses one key per line in the form "<base64 public key> [comment]".
+// Empty lines and lines starting with # are ignored.
+func ParseKeyList(data []byte) ([]ed25519.PublicKey, error) {
+	var keys []ed25519.PublicKey
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(strings.Fields(line)[0])
+		if err != nil || len(raw) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid public key: %s", line)
+		}
+		keys = append(keys, ed25519.PublicKey(raw))
+	}
+	return keys, scanner.Err()
+}
+
+// ParseIDList parses one key ID per line; empty lines and # comments are ignored
+func ParseIDList(data []byte) []string {
+	var ids []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ids = append(ids, strings.Fields(line)[0])
+	}
+	return ids
+}
+
+// NewKeyRing creates a key ring from trusted keys and revoked key IDs
+func NewKeyRing(keys []ed25519.PublicKey, revoked []string) *KeyRing {
+	k := &KeyRing{
+		keys:    make(map[string]ed25519.PublicKey),
+		revoked: make(map[string]bool),
+	}
+	for _, pub := range keys {
+		k.keys[KeyID(pub)] = pub
+	}
+	k.Revoke(revoked...)
+	return k
+}
+
+// Revoke marks key IDs as no longer trusted
+func (k *KeyRing) Revoke(ids ...string) {
+	for _, id := range ids {
+		k.revoked[strings.ToLower(id)] = true
+	}
+}
+
+// Empty reports whether the key ring has no usable (non-revoked) keys
+func (k *KeyRing) Empty() bool {
+	for id := range k.keys {
+		if !k.revoked[id] {
+			return false
+		}
+	}
+	return true
+}
+
+// Verify checks a detached signature file against the content of r.
+// It succeeds if at least one signature was made by a trusted, non-revoked key.
+func (k *KeyRing) Verify(r io.Reader, sigData []byte) error {
+	if k.Empty() {
+		return ErrNoTrustedKeys
+	}
+
+	var sigFile SignatureFile
+	if err := json.Unmarshal(sigData, &sigFile); err != nil {
+		return fmt.Errorf("invalid signature file: %v", err)
+	}
+
+	digest, err := Digest(r)
+	if err != nil {
+		return err
+	}
+
+	lastErr := errors.New("no signature from a trusted key")
+	for _, sig := range sigFile.Signatures {
+		id := strings.ToLower(sig.KeyID)
+		if k.revoked[id] {
+			lastErr = fmt.Errorf("signing key %s has been revoked", sig.KeyID)
+			continue
+		}
+		pub, ok := k.keys[id]
+		if !ok {
+			continue
+		}
+		if sig.Algorithm != Algorithm {
+			lastErr = fmt.Errorf("unsupported signature algorithm: %s", sig.Algorithm)
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(sig.Signature)
+		if err != nil {
+			lastErr = fmt.Errorf("invalid signature encoding: %v", err)
+			continue
+		}
+		if err := ed25519.VerifyWithOptions(pub, digest, raw, &ed25519.Options{Hash: crypto.SHA512}); err != nil {
+			lastErr = fmt.Errorf("signature by key %s is invalid", sig.KeyID)
+			continue
+		}
+		return nil
+	}
+	return lastErr
+}
+
+// VerifyFile verifies the file at path against a detached signature
+func (k *KeyRing) VerifyFile(path string, sigData []byte) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return k.Verify(f, sigData)
+}
+
+// Sign creates a signature for the content of r
+func Sign(priv ed25519.PrivateKey, r io.Reader) (Signature, error) {
+	digest, err := Digest(r)
+	if err != nil {
+		return Signature{}, err
+	}
+	raw, err := priv.Sign(nil, digest, &ed25519.Options{Hash: crypto.SHA512})
+	if err != nil {
+		return Signature{}, err
+	}
+	return Signature{
+		KeyID:     KeyID(priv.Public().(ed25519.PublicKey)),
+		Algorithm: Algorithm,
+		Signature: base64.StdEncoding.EncodeToString(raw),
+	}, nil
+}
+
+// Digest computes the SHA-512 prehash used for Ed25519ph
+func Digest(r io.Reader) ([]byte, error) {
+	h := sha512.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
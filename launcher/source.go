@@ -0,0 +1,345 @@
+/**
+ * LTTH Launcher - Update Sources
+ *
+ * Release metadata comes from an UpdateSource selected by updateSource in the
+ * launcher config: the signed version.json feed (default) or the GitHub
+ * Releases API of githubRepository. Both produce a VersionInfo, so channels,
+ * the catalogue and installs work the same for either. Responses of both are
+ * cached (see cache.go).
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Update source names for updateSource in the launcher config
+const (
+	SourceFeed   = "feed"
+	SourceGitHub = "github"
+)
+
+const (
+	// gitHubAPIURL is the GitHub REST API used unless githubApiUrl overrides it
+	gitHubAPIURL = "https://api.github.com"
+	// gitHubReleasesPerPage is how many of the newest releases are read
+	gitHubReleasesPerPage = 50
+)
+
+// UpdateSource provides the release metadata the launcher installs from.
+// Cached returns the last metadata fetched from it regardless of its age, or
+// nil if there is none.
+type UpdateSource interface {
+	Name() string
+	Fetch() (*VersionInfo, error)
+	Cached() *VersionInfo
+}
+
+// isValidSource reports whether name is a known update source
+func isValidSource(name string) bool {
+	return name == SourceFeed || name == SourceGitHub
+}
+
+// currentUpdateSource returns the update source selected in the config
+func currentUpdateSource() UpdateSource {
+	if currentConfig().UpdateSource == SourceGitHub {
+		return newGitHubSource()
+	}
+	return feedSource{}
+}
+
+// feedSource reads the signed version.json from the configured mirrors
+type feedSource struct{}
+
+func (feedSource) Name() string { return SourceFeed }
+
+// Fetch downloads, verifies and parses version.json from the first mirror
+// that delivers a valid one
+func (feedSource) Fetch() (*VersionInfo, error) {
+	var lastErr error
+	for _, source := range metadataSources() {
+		info, err := fetchVersionInfoFrom(source.URL)
+		if err != nil {
+			recordMirrorFailure(source.Mirror, err)
+			lastErr = err
+			continue
+		}
+		recordMirrorSuccess(source.Mirror)
+		return info, nil
+	}
+	return nil, lastErr
+}
+
+// Cached returns the most recently fetched version.json of any mirror
+func (feedSource) Cached() *VersionInfo {
+	var newest *VersionInfo
+	for _, source := range metadataSources() {
+		cached := loadCachedResponse(source.URL)
+		if cached == nil {
+			continue
+		}
+		info, err := versionInfoFromCache(cached)
+		if err != nil {
+			log.Printf("Discarding cached %s: %v", source.URL, err)
+			continue
+		}
+		if newest == nil || info.FetchedAt.After(newest.FetchedAt) {
+			newest = info
+		}
+	}
+	return newest
+}
+
+// versionInfoFromCache verifies and parses a cached version.json
+func versionInfoFromCache(cached *cachedResponse) (*VersionInfo, error) {
+	if err := verifyCachedManifest(cached.Data, cached.Signature, path.Base(cached.URL)); err != nil {
+		return nil, err
+	}
+	info, err := parseVersionInfo(cached.Data)
+	if err != nil {
+		return nil, err
+	}
+	applyRevocations(info.RevokedKeys)
+	info.FetchedAt = cached.FetchedAt
+	return info, nil
+}
+
+// gitHubSource builds the version information from the GitHub Releases API.
+// The API response isn't signed; archives are still checked against their
+// published digest and their detached signature (<asset>.sig).
+type gitHubSource struct {
+	APIURL     string
+	Repository string
+	Client     *http.Client
+}
+
+// newGitHubSource creates a source for the configured repository
+func newGitHubSource() *gitHubSource {
+	source := &gitHubSource{
+		APIURL:     gitHubAPIURL,
+		Repository: GitHubOwner + "/" + GitHubRepo,
+		Client:     httpClient(),
+	}
+	cfg := currentConfig()
+	if cfg.GitHubRepository != "" {
+		source.Repository = cfg.GitHubRepository
+	}
+	if cfg.GitHubAPIURL != "" {
+		source.APIURL = strings.TrimSuffix(cfg.GitHubAPIURL, "/")
+	}
+	return source
+}
+
+func (s *gitHubSource) Name() string { return SourceGitHub }
+
+// gitHubRelease is the part of a Releases API release the launcher reads
+type gitHubRelease struct {
+	TagName     string        `json:"tag_name"`
+	Name        string        `json:"name"`
+	Body        string        `json:"body"`
+	Draft       bool          `json:"draft"`
+	Prerelease  bool          `json:"prerelease"`
+	PublishedAt time.Time     `json:"published_at"`
+	Assets      []gitHubAsset `json:"assets"`
+}
+
+// gitHubAsset is one file attached to a release
+type gitHubAsset struct {
+	Name               string `json:"name"`
+	Size               int64  `json:"size"`
+	Digest             string `json:"digest"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+// Fetch reads the newest releases and converts them to version information.
+// GitHub doesn't count revalidations answered with "304 Not Modified" against
+// the rate limit.
+func (s *gitHubSource) Fetch() (*VersionInfo, error) {
+	endpoint, err := s.endpoint()
+	if err != nil {
+		return nil, err
+	}
+	cached := loadCachedResponse(endpoint)
+	if cached != nil {
+		info, err := releasesFromCache(cached)
+		if err != nil {
+			log.Printf("Discarding cached %s: %v", endpoint, err)
+			cached = nil
+		} else if cached.fresh(time.Now()) {
+			return info, nil
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	response, _, err := fetchConditional(s.Client, req, cached)
+	var status *httpStatusError
+	if errors.As(err, &status) && status.Header.Get("X-RateLimit-Remaining") == "0" {
+		return nil, fmt.Errorf("GitHub API rate limit exceeded, try again later")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := releasesFromCache(response)
+	if err != nil {
+		return nil, err
+	}
+	storeCachedResponse(response)
+	return info, nil
+}
+
+// Cached returns the last fetched releases of the repository
+func (s *gitHubSource) Cached() *VersionInfo {
+	endpoint, err := s.endpoint()
+	if err != nil {
+		return nil
+	}
+	cached := loadCachedResponse(endpoint)
+	if cached == nil {
+		return nil
+	}
+	info, err := releasesFromCache(cached)
+	if err != nil {
+		return nil
+	}
+	return info
+}
+
+// endpoint returns the Releases API URL of the repository
+func (s *gitHubSource) endpoint() (string, error) {
+	if !strings.HasPrefix(s.APIURL, "https://") {
+		return "", fmt.Errorf("GitHub API URL must use https: %s", s.APIURL)
+	}
+	owner, repo, ok := strings.Cut(s.Repository, "/")
+	if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return "", fmt.Errorf("invalid GitHub repository %q, expected owner/repo", s.Repository)
+	}
+	return fmt.Sprintf("%s/repos/%s/%s/releases?per_page=%d", s.APIURL, url.PathEscape(owner), url.PathEscape(repo), gitHubReleasesPerPage), nil
+}
+
+// releasesFromCache parses a cached Releases API response
+func releasesFromCache(cached *cachedResponse) (*VersionInfo, error) {
+	var releases []gitHubRelease
+	if err := json.Unmarshal(cached.Data, &releases); err != nil {
+		return nil, fmt.Errorf("invalid GitHub releases response: %v", err)
+	}
+	info := releasesToVersionInfo(releases)
+	info.FetchedAt = cached.FetchedAt
+	return info, nil
+}
+
+// releasesToVersionInfo converts GitHub releases to version information.
+// Prereleases are published in the beta channel, or nightly if their tag says
+// so; releases without a usable archive asset are skipped.
+func releasesToVersionInfo(releases []gitHubRelease) *VersionInfo {
+	info := &VersionInfo{
+		Changelog: make(map[string]ChangelogEntry),
+		Channels:  make(map[string]ChannelRelease),
+		Artifacts: make(map[string]ArtifactInfo),
+	}
+
+	for _, release := range releases {
+		version := strings.TrimPrefix(release.TagName, "v")
+		if release.Draft || !isValidVersionName(version) {
+			continue
+		}
+		asset, ok := releaseArchive(release.Assets)
+		if !ok {
+			log.Printf("GitHub release %s has no archive asset, skipped", release.TagName)
+			continue
+		}
+
+		date := ""
+		if !release.PublishedAt.IsZero() {
+			date = release.PublishedAt.Format("2006-01-02")
+		}
+		info.Changelog[version] = ChangelogEntry{Date: date, Changes: releaseNotes(release.Body)}
+		info.Artifacts[version] = ArtifactInfo{
+			URL:                asset.BrowserDownloadURL,
+			MediaType:          mediaTypeFromName(asset.Name),
+			SHA256:             strings.TrimPrefix(asset.Digest, "sha256:"),
+			Size:               asset.Size,
+			MinLauncherVersion: releaseMinLauncher(release.Body),
+		}
+
+		channel := ChannelStable
+		if release.Prerelease {
+			channel = ChannelBeta
+			if strings.Contains(strings.ToLower(release.TagName), ChannelNightly) {
+				channel = ChannelNightly
+			}
+		}
+		if current, ok := info.Channels[channel]; !ok || compareVersions(version, current.Version) > 0 {
+			info.Channels[channel] = ChannelRelease{Version: version, ReleaseDate: date}
+		}
+	}
+
+	if stable, ok := info.Channels[ChannelStable]; ok {
+		info.Version, info.ReleaseDate, info.Status = stable.Version, stable.ReleaseDate, ChannelStable
+	}
+	return info
+}
+
+// releaseArchive picks the release archive among the assets of a release,
+// preferring ZIP over tarballs
+func releaseArchive(assets []gitHubAsset) (gitHubAsset, bool) {
+	var best gitHubAsset
+	found := false
+	for _, asset := range assets {
+		name := strings.ToLower(asset.Name)
+		if !strings.HasPrefix(name, "ltth") || !strings.HasPrefix(asset.BrowserDownloadURL, "https://") {
+			continue
+		}
+		mediaType := mediaTypeFromName(name)
+		if !strings.HasSuffix(name, archiveExtensions[mediaType]) && !strings.HasSuffix(name, ".tgz") && !strings.HasSuffix(name, ".tzst") {
+			continue
+		}
+		if !found || (mediaType == mediaTypeZip && mediaTypeFromName(best.Name) != mediaTypeZip) {
+			best, found = asset, true
+		}
+	}
+	return best, found
+}
+
+// releaseNotes turns a Markdown release body into changelog lines
+func releaseNotes(body string) []string {
+	var changes []string
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "<!--") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimLeft(line, "-*+"))
+		if line != "" {
+			changes = append(changes, line)
+		}
+	}
+	return changes
+}
+
+// minLauncherPattern finds "<!-- minLauncherVersion: 1.0.1 -->" in release notes
+var minLauncherPattern = regexp.MustCompile(`<!--\s*minLauncherVersion:\s*([^>]+?)\s*-->`)
+
+// releaseMinLauncher returns the launcher requirement stated in release notes
+func releaseMinLauncher(body string) string {
+	if m := minLauncherPattern.FindStringSubmatch(body); m != nil {
+		return m[1]
+	}
+	return ""
+}
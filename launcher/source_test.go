@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// releasesAPI stands in for the GitHub Releases API of one repository
+type releasesAPI struct {
+	mu          sync.Mutex
+	releases    []gitHubRelease
+	etag        string
+	rateLimited bool
+	requests    []*http.Request
+	statuses    []int
+}
+
+func (api *releasesAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	status := http.StatusOK
+	defer func() {
+		api.requests = append(api.requests, r)
+		api.statuses = append(api.statuses, status)
+	}()
+
+	switch {
+	case r.URL.Path != "/repos/ltth/launcher/releases":
+		status = http.StatusNotFound
+	case api.rateLimited:
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		status = http.StatusForbidden
+	case r.Header.Get("If-None-Match") == api.etag:
+		status = http.StatusNotModified
+	}
+	if status != http.StatusOK {
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("ETag", api.etag)
+	json.NewEncoder(w).Encode(api.releases)
+}
+
+// newTestGitHubSource starts a stand-in Releases API and returns a source
+// reading from it, with the manifest cache in a temporary folder
+func newTestGitHubSource(t *testing.T, api *releasesAPI) (*gitHubSource, *httptest.Server) {
+	t.Helper()
+	oldPath := configPath
+	configPath = filepath.Join(t.TempDir(), "config.json")
+	stateMu.Lock()
+	oldConfig := config
+	config = LauncherConfig{}
+	stateMu.Unlock()
+	t.Cleanup(func() {
+		configPath = oldPath
+		stateMu.Lock()
+		config = oldConfig
+		stateMu.Unlock()
+	})
+
+	ts := httptest.NewTLSServer(api)
+	t.Cleanup(ts.Close)
+	return &gitHubSource{APIURL: ts.URL, Repository: "ltth/launcher", Client: ts.Client()}, ts
+}
+
+// testReleases returns releases covering every channel and skipped release,
+// with assets served from base
+func testReleases(base string) []gitHubRelease {
+	published := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	asset := func(name string) gitHubAsset {
+		return gitHubAsset{Name: name, Size: 1000, Digest: "sha256:" + strings.Repeat("a", 64), BrowserDownloadURL: base + "/download/" + name}
+	}
+	return []gitHubRelease{
+		{TagName: "v2.0.0", Draft: true, Assets: []gitHubAsset{asset("ltth-2.0.0.zip")}},
+		{TagName: "v1.4.0-nightly.20260301", Prerelease: true, PublishedAt: published, Assets: []gitHubAsset{asset("ltth-nightly.tar.zst")}},
+		{TagName: "v1.3.0-beta.1", Prerelease: true, PublishedAt: published, Assets: []gitHubAsset{asset("ltth-1.3.0-beta.1.zip")}},
+		{TagName: "v1.2.1", PublishedAt: published, Assets: []gitHubAsset{asset("notes.txt")}},
+		{
+			TagName:     "v1.2.0",
+			PublishedAt: published,
+			Body:        "## Changes\n- Faster startup\n* Fixed tray icon\n<!-- minLauncherVersion: 1.0.1 -->",
+			Assets: []gitHubAsset{
+				asset("source.zip"),
+				asset("ltth-1.2.0-linux.tar.gz"),
+				asset("ltth-1.2.0.zip"),
+				asset("ltth-1.2.0.zip.sig"),
+			},
+		},
+		{TagName: "v1.1.0", PublishedAt: published.AddDate(0, -1, 0), Assets: []gitHubAsset{asset("ltth-1.1.0.tgz")}},
+	}
+}
+
+func TestGitHubSourceFetch(t *testing.T) {
+	api := &releasesAPI{etag: `"r1"`}
+	source, ts := newTestGitHubSource(t, api)
+	api.releases = testReleases(ts.URL)
+
+	info, err := source.Fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantChannels := map[string]ChannelRelease{
+		ChannelStable:  {Version: "1.2.0", ReleaseDate: "2026-03-01"},
+		ChannelBeta:    {Version: "1.3.0-beta.1", ReleaseDate: "2026-03-01"},
+		ChannelNightly: {Version: "1.4.0-nightly.20260301", ReleaseDate: "2026-03-01"},
+	}
+	if !reflect.DeepEqual(info.Channels, wantChannels) {
+		t.Errorf("Channels = %v, want %v", info.Channels, wantChannels)
+	}
+	if info.Version != "1.2.0" || info.Status != ChannelStable {
+		t.Errorf("Version = %s (%s), want 1.2.0 (stable)", info.Version, info.Status)
+	}
+	for _, skipped := range []string{"2.0.0", "1.2.1"} {
+		if _, ok := info.Artifacts[skipped]; ok {
+			t.Errorf("release %s should be skipped", skipped)
+		}
+	}
+
+	artifact := info.Artifacts["1.2.0"]
+	want := ArtifactInfo{
+		URL:                ts.URL + "/download/ltth-1.2.0.zip",
+		MediaType:          mediaTypeZip,
+		SHA256:             strings.Repeat("a", 64),
+		Size:               1000,
+		MinLauncherVersion: "1.0.1",
+	}
+	if artifact != want {
+		t.Errorf("Artifacts[1.2.0] = %+v, want %+v", artifact, want)
+	}
+	if got := info.Artifacts["1.1.0"].MediaType; got != mediaTypeTarGz {
+		t.Errorf("Artifacts[1.1.0].MediaType = %s, want %s", got, mediaTypeTarGz)
+	}
+	if got := info.Changelog["1.2.0"].Changes; !reflect.DeepEqual(got, []string{"Faster startup", "Fixed tray icon"}) {
+		t.Errorf("Changelog[1.2.0] = %q", got)
+	}
+
+	req := api.requests[0]
+	if req.Header.Get("Accept") != "application/vnd.github+json" || req.URL.Query().Get("per_page") != "50" {
+		t.Errorf("unexpected request %s with Accept %q", req.URL, req.Header.Get("Accept"))
+	}
+}
+
+func TestGitHubSourceRevalidatesCache(t *testing.T) {
+	api := &releasesAPI{etag: `"r1"`}
+	source, ts := newTestGitHubSource(t, api)
+	api.releases = testReleases(ts.URL)
+
+	first, err := source.Fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// manifestMaxAge is 0, so the cached copy is revalidated right away
+	second, err := source.Fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []int{http.StatusOK, http.StatusNotModified}; !reflect.DeepEqual(api.statuses, want) {
+		t.Fatalf("responses = %v, want %v", api.statuses, want)
+	}
+	if got := api.requests[1].Header.Get("If-None-Match"); got != `"r1"` {
+		t.Errorf("If-None-Match = %q, want the cached ETag", got)
+	}
+	if !reflect.DeepEqual(first.Channels, second.Channels) || !reflect.DeepEqual(first.Artifacts, second.Artifacts) {
+		t.Error("revalidated releases differ from the cached ones")
+	}
+	if cached := source.Cached(); cached == nil || cached.Version != "1.2.0" {
+		t.Errorf("Cached() = %v, want the fetched releases", cached)
+	}
+}
+
+func TestGitHubSourceRateLimit(t *testing.T) {
+	api := &releasesAPI{etag: `"r1"`, rateLimited: true}
+	source, _ := newTestGitHubSource(t, api)
+
+	_, err := source.Fetch()
+	if err == nil || !strings.Contains(err.Error(), "rate limit") {
+		t.Fatalf("Fetch() error = %v, want the rate limit error", err)
+	}
+}
+
+func TestGitHubSourceEndpoint(t *testing.T) {
+	tests := []struct {
+		apiURL, repository string
+		ok                 bool
+	}{
+		{"https://api.github.com", "ltth/launcher", true},
+		{"http://api.github.com", "ltth/launcher", false},
+		{"https://api.github.com", "ltth", false},
+		{"https://api.github.com", "ltth/launcher/extra", false},
+		{"https://api.github.com", "/launcher", false},
+	}
+	for _, tt := range tests {
+		source := &gitHubSource{APIURL: tt.apiURL, Repository: tt.repository}
+		if _, err := source.endpoint(); (err == nil) != tt.ok {
+			t.Errorf("endpoint(%s, %s) error = %v, want ok = %v", tt.apiURL, tt.repository, err, tt.ok)
+		}
+	}
+}
+
+func TestReleaseArchive(t *testing.T) {
+	asset := func(name string) gitHubAsset {
+		return gitHubAsset{Name: name, BrowserDownloadURL: "https://github.com/ltth/launcher/releases/download/v1/" + name}
+	}
+	tests := []struct {
+		name   string
+		assets []gitHubAsset
+		want   string
+	}{
+		{"zip preferred over tarball", []gitHubAsset{asset("ltth.tar.gz"), asset("ltth.zip"), asset("ltth.tar.zst")}, "ltth.zip"},
+		{"first tarball without zip", []gitHubAsset{asset("ltth.tar.zst"), asset("ltth.tar.gz")}, "ltth.tar.zst"},
+		{"short tarball extensions", []gitHubAsset{asset("LTTH-linux.tgz")}, "LTTH-linux.tgz"},
+		{"other prefixes ignored", []gitHubAsset{asset("source.zip"), asset("ltth.zip")}, "ltth.zip"},
+		{"signatures ignored", []gitHubAsset{asset("ltth.zip.sig"), asset("ltth.exe")}, ""},
+		{"insecure download ignored", []gitHubAsset{{Name: "ltth.zip", BrowserDownloadURL: "http://example.com/ltth.zip"}}, ""},
+		{"no assets", nil, ""},
+	}
+	for _, tt := range tests {
+		got, ok := releaseArchive(tt.assets)
+		if ok != (tt.want != "") || got.Name != tt.want {
+			t.Errorf("%s: releaseArchive() = %q, %v; want %q", tt.name, got.Name, ok, tt.want)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+/**
+ * LTTH Launcher - Shared File Store
+ *
+ * Files of installed versions are stored once under InstallPath/.store by
+ * their SHA256 and hardlinked into each version folder, so versions that
+ * share most of their files (node_modules) take the space of one. On file
+ * systems without hardlinks the version folders simply keep full copies.
+ * Linked files share permissions and modification time with the blob, so a
+ * file whose permissions differ from the blob keeps its own copy.
+ *
+ * Blobs no version manifest references any more are garbage collected.
+ */
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// storeDirName is the shared store below InstallPath
+const storeDirName = ".store"
+
+// blobPath returns the store location of content with the given SHA256
+func blobPath(sum string) string {
+	sum = strings.ToLower(sum)
+	return filepath.Join(currentConfig().InstallPath, storeDirName, sum[:2], sum)
+}
+
+// linkIntoStore replaces the files of a staged version with hardlinks into the
+// shared store, adding content the store doesn't have yet
+func linkIntoStore(dir string) error {
+	if !currentConfig().SharedStore {
+		return nil
+	}
+	manifest, err := loadInstallManifest(dir)
+	if err != nil {
+		return err
+	}
+
+	linked, shared := 0, int64(0)
+	for _, file := range manifest.Files {
+		if len(file.SHA256) != 64 {
+			continue
+		}
+		path := filepath.Join(dir, filepath.FromSlash(file.Path))
+		reused, err := linkFile(path, file)
+		if err != nil {
+			// Typically FAT32/exFAT or a store on another volume: keep plain copies
+			log.Printf("Shared store not usable, keeping full copies: %v", err)
+			return nil
+		}
+		linked++
+		if reused {
+			shared += file.Size
+		}
+	}
+
+	log.Printf("Shared store: %d files linked, %d bytes shared with other versions", linked, shared)
+	return nil
+}
+
+// linkFile links path with the blob of its content and reports whether the
+// blob already existed. A blob whose content doesn't match its name is
+// replaced by path, so damage never spreads into new versions.
+func linkFile(path string, file ManifestFile) (bool, error) {
+	blob := blobPath(file.SHA256)
+	existing, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	info, err := os.Stat(blob)
+	if err == nil && os.SameFile(existing, info) {
+		return true, nil
+	}
+	intact := err == nil && verifyDigest(blob, file.Size, file.SHA256) == nil
+	if err == nil && !intact {
+		log.Printf("Shared store: replacing damaged blob %s", filepath.Base(blob))
+	}
+	if !intact {
+		// First intact copy of this content: the file itself becomes the blob
+		os.Remove(blob)
+		if err := os.MkdirAll(filepath.Dir(blob), 0755); err != nil {
+			return false, err
+		}
+		return false, os.Link(path, blob)
+	}
+
+	if existing.Mode().Perm() != info.Mode().Perm() {
+		return false, nil
+	}
+	tmp := path + ".link"
+	os.Remove(tmp)
+	if err := os.Link(blob, tmp); err != nil {
+		return false, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return false, err
+	}
+	return true, nil
+}
+
+// collectStoreGarbage removes blobs that no installed version references
+func collectStoreGarbage() error {
+	installPath := currentConfig().InstallPath
+	if installPath == "" {
+		return nil
+	}
+	storeDir := filepath.Join(installPath, storeDirName)
+	if _, err := os.Stat(storeDir); err != nil {
+		return nil
+	}
+
+	entries, err := os.ReadDir(installPath)
+	if err != nil {
+		return err
+	}
+	referenced := make(map[string]bool)
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		manifest, err := loadInstallManifest(filepath.Join(installPath, entry.Name()))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			// Without knowing what this version uses nothing can be removed safely
+			return fmt.Errorf("version %s: %v", entry.Name(), err)
+		}
+		for _, file := range manifest.Files {
+			referenced[strings.ToLower(file.SHA256)] = true
+		}
+	}
+
+	removed, freed := 0, int64(0)
+	prefixes, err := os.ReadDir(storeDir)
+	if err != nil {
+		return err
+	}
+	for _, prefix := range prefixes {
+		prefixDir := filepath.Join(storeDir, prefix.Name())
+		blobs, err := os.ReadDir(prefixDir)
+		if err != nil {
+			continue
+		}
+		for _, blob := range blobs {
+			if referenced[blob.Name()] {
+				continue
+			}
+			info, err := blob.Info()
+			if err != nil {
+				continue
+			}
+			if err := os.Remove(filepath.Join(prefixDir, blob.Name())); err == nil {
+				removed++
+				freed += info.Size()
+			}
+		}
+		// Only succeeds once the prefix folder is empty
+		os.Remove(prefixDir)
+	}
+
+	if removed > 0 {
+		log.Printf("Shared store: removed %d unused files (%d bytes)", removed, freed)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+/**
+ * LTTH Launcher - Tarball Extraction
+ *
+ * tar.gz and tar.zst archives are read as a single stream, so entries are
+ * checked one by one while unpacking and progress is reported in compressed
+ * bytes. The compression ratio is checked for the archive as a whole.
+ *
+ * Symbolic links are created after all files are written, so nothing is ever
+ * written through a link, and only if their target stays inside the archive
+ * without passing through another link.
+ */
+
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// tarLink is a symbolic link of a tarball, created once all files are in place
+type tarLink struct {
+	name   string
+	target string
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// extractTarGzFiles extracts a gzip compressed tarball
+func extractTarGzFiles(src, dest string, only map[string]bool, report ProgressFunc) ([]ManifestFile, error) {
+	return extractTarFiles(src, dest, only, report, func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	})
+}
+
+// extractTarZstFiles extracts a zstd compressed tarball
+func extractTarZstFiles(src, dest string, only map[string]bool, report ProgressFunc) ([]ManifestFile, error) {
+	return extractTarFiles(src, dest, only, report, func(r io.Reader) (io.ReadCloser, error) {
+		d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	})
+}
+
+// extractTarFiles extracts the files whose slash-separated path is in only,
+// or every file if only is nil, from a tarball decompressed by decompress and
+// returns size and hash of each written file
+func extractTarFiles(src, dest string, only map[string]bool, report ProgressFunc, decompress func(io.Reader) (io.ReadCloser, error)) ([]ManifestFile, error) {
+	archive, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+	info, err := archive.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	progress := newProgressTracker(PhaseExtract, UnitBytes, info.Size(), report)
+	compressed := &countingReader{r: archive}
+	stream, err := decompress(io.TeeReader(compressed, progress))
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+	tr := tar.NewReader(stream)
+
+	// Clean and normalize destination path
+	cleanDest := filepath.Clean(dest)
+	os.MkdirAll(cleanDest, 0755)
+
+	limits := extractLimits()
+	entries, written := 0, int64(0)
+	var files []ManifestFile
+	var links []tarLink
+	var dirs []extractedDir
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if hdr.Typeflag == tar.TypeXGlobalHeader {
+			continue
+		}
+		entries++
+		if entries > limits.MaxEntries {
+			return nil, unsafeArchive("more than %d entries", limits.MaxEntries)
+		}
+
+		name := path.Clean(strings.TrimPrefix(filepath.ToSlash(hdr.Name), "./"))
+		mode := hdr.FileInfo().Mode()
+		if mode&os.ModeSymlink != 0 {
+			if !filepath.IsLocal(filepath.FromSlash(name)) {
+				return nil, unsafeArchive("invalid file path: %s", hdr.Name)
+			}
+			if only == nil || only[name] {
+				links = append(links, tarLink{name: name, target: hdr.Linkname})
+			}
+			continue
+		}
+		if hdr.Typeflag == tar.TypeLink {
+			return nil, unsafeArchive("hard link: %s", hdr.Name)
+		}
+		if err := checkEntry(hdr.Name, mode, uint64(hdr.Size), limits); err != nil {
+			return nil, err
+		}
+
+		fpath := filepath.Join(cleanDest, filepath.FromSlash(name))
+		if mode.IsDir() {
+			if only == nil {
+				os.MkdirAll(fpath, 0755)
+				dirs = append(dirs, extractedDir{fpath, hdr.ModTime})
+			}
+			continue
+		}
+		if only != nil && !only[name] {
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
+			return nil, err
+		}
+		file, err := writeEntry(tr, hdr.Name, fpath, mode, hdr.ModTime, hdr.Size, limits, limits.MaxTotalSize-written)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+		written += file.Size
+		if err := checkRatio(src, uint64(written), uint64(compressed.n), limits); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkTarLinks(links); err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		fpath := filepath.Join(cleanDest, filepath.FromSlash(link.name))
+		if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
+			return nil, err
+		}
+		if only != nil {
+			os.Remove(fpath)
+		}
+		if err := os.Symlink(filepath.FromSlash(link.target), fpath); err != nil {
+			return nil, err
+		}
+	}
+	restoreDirTimes(dirs)
+
+	progress.Finish()
+	return files, nil
+}
+
+// checkTarLinks rejects links whose target is absolute, leaves the archive or
+// resolves through another link of the archive
+func checkTarLinks(links []tarLink) error {
+	names := make(map[string]bool, len(links))
+	for _, link := range links {
+		names[link.name] = true
+	}
+
+	for _, link := range links {
+		if link.target == "" || path.IsAbs(link.target) || filepath.IsAbs(link.target) || filepath.VolumeName(link.target) != "" {
+			return unsafeArchive("symbolic link %s points outside the archive: %s", link.name, link.target)
+		}
+		current := path.Dir(link.name)
+		for _, part := range strings.Split(filepath.ToSlash(link.target), "/") {
+			switch part {
+			case "", ".":
+				continue
+			case "..":
+				if current == "." {
+					return unsafeArchive("symbolic link %s points outside the archive: %s", link.name, link.target)
+				}
+				current = path.Dir(current)
+			default:
+				current = path.Join(current, part)
+				if names[current] {
+					return unsafeArchive("symbolic link %s points through another link: %s", link.name, link.target)
+				}
+			}
+		}
+	}
+	return nil
+}